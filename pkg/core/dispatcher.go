@@ -2,8 +2,54 @@ package core
 
 import (
 	"context"
+	"errors"
+	"time"
 )
 
 type OutboxMessageDispatcher interface {
 	Dispatch(ctx context.Context) error
 }
+
+// OutboxMessageNotifier signals that new messages may be pending, so a dispatcher can fetch them
+// without waiting for its next poll. Notifications may be coalesced or lost, as they only cut
+// latency; a closed channel means no further notifications will arrive.
+type OutboxMessageNotifier interface {
+	Notifications() <-chan struct{}
+}
+
+// ErrNotLeader is returned by OutboxLeaderElector when another dispatcher is the leader.
+var ErrNotLeader = errors.New("another dispatcher is the leader")
+
+// OutboxLeaderElector restricts dispatching to a single active dispatcher.
+type OutboxLeaderElector interface {
+	// AcquireLeadership tries to become the leader without waiting for the current one. It returns
+	// ErrNotLeader when another dispatcher leads. Otherwise, it returns a context derived from ctx
+	// that is canceled once leadership is lost, and a function that gives leadership up.
+	AcquireLeadership(ctx context.Context) (context.Context, func(), error)
+}
+
+// OutboxPartitionLeaser assigns the partitions a partitioned repository fetches to this
+// dispatcher. Run keeps the assignment up to date until ctx is done, then gives it up.
+type OutboxPartitionLeaser interface {
+	Run(ctx context.Context) error
+}
+
+// OutboxObserver is notified of what dispatchers and janitors do, such as to export metrics. Its
+// methods are called synchronously, so they must return quickly. Embed NopOutboxObserver to only
+// implement some of them.
+type OutboxObserver interface {
+	MessagePublished(message OutboxMessage)
+	MessageRetried(message OutboxMessage, delay time.Duration, err error)
+	MessageFailed(message OutboxMessage, err error)
+	MessagesCleanedUp(status MessageStatus, count int)
+	PartitionsDropped(count int)
+}
+
+// NopOutboxObserver ignores every event.
+type NopOutboxObserver struct{}
+
+func (NopOutboxObserver) MessagePublished(message OutboxMessage)                               {}
+func (NopOutboxObserver) MessageRetried(message OutboxMessage, delay time.Duration, err error) {}
+func (NopOutboxObserver) MessageFailed(message OutboxMessage, err error)                       {}
+func (NopOutboxObserver) MessagesCleanedUp(status MessageStatus, count int)                    {}
+func (NopOutboxObserver) PartitionsDropped(count int)                                          {}
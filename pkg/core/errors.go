@@ -0,0 +1,58 @@
+package core
+
+import (
+	"errors"
+	"time"
+)
+
+// PermanentError marks a publish failure that retrying cannot fix, such as a rejected payload.
+// The dispatcher marks such messages as failed without using the remaining retry attempts.
+type PermanentError struct {
+	Err error
+}
+
+func NewPermanentError(err error) error {
+	return &PermanentError{Err: err}
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+func IsPermanentError(err error) bool {
+	var permanentErr *PermanentError
+	return errors.As(err, &permanentErr)
+}
+
+// RetryAfterError marks a publish failure for which the destination asked to wait before retrying.
+// The dispatcher waits at least RetryAfter before the next attempt.
+type RetryAfterError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func NewRetryAfterError(err error, retryAfter time.Duration) error {
+	return &RetryAfterError{Err: err, RetryAfter: retryAfter}
+}
+
+func (e *RetryAfterError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+// RetryAfter returns the delay requested by a RetryAfterError in the error's chain, if any.
+func RetryAfter(err error) (time.Duration, bool) {
+	var retryAfterErr *RetryAfterError
+	if errors.As(err, &retryAfterErr) {
+		return retryAfterErr.RetryAfter, true
+	}
+
+	return 0, false
+}
@@ -3,14 +3,23 @@ package core
 import "time"
 
 type OutboxMessage struct {
-	ID          string        `json:"id"`
-	Payload     string        `json:"payload"`
-	Status      MessageStatus `json:"status"`
-	Attempts    uint8         `json:"attempts"`
-	AvailableAt time.Time     `json:"available_at"`
-	CreatedAt   time.Time     `json:"created_at"`
+	ID          string            `json:"id"`
+	Payload     string            `json:"payload"`
+	Headers     map[string]string `json:"headers"`
+	OrderingKey string            `json:"ordering_key"` // Key of the aggregate or entity whose messages must stay in order.
+	Status      MessageStatus     `json:"status"`
+	Attempts    uint8             `json:"attempts"`
+	AvailableAt time.Time         `json:"available_at"` // When the message may be dispatched. Saving it with a future time schedules it.
+	CreatedAt   time.Time         `json:"created_at"`
 }
 
+// Message headers describing the domain event a message carries. The ordering key is the ID of
+// the aggregate. They match the columns read by Debezium's outbox event router.
+const (
+	HeaderAggregateType = "aggregate-type"
+	HeaderEventType     = "event-type"
+)
+
 type MessageStatus string
 
 const (
@@ -27,3 +36,10 @@ func (m *OutboxMessage) GetRetryAttempts() uint8 {
 
 	return m.Attempts - 1 // The first attempt is not a retry attempt
 }
+
+// ScheduleIn schedules the message to be dispatched once delay elapsed, when it is saved.
+// Scheduled messages are dispatched in the order of their time, not in the order of their
+// ordering key.
+func (m *OutboxMessage) ScheduleIn(delay time.Duration) {
+	m.AvailableAt = time.Now().Add(delay)
+}
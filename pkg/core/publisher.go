@@ -2,8 +2,41 @@ package core
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
 )
 
 type OutboxMessagePublisher interface {
 	Publish(ctx context.Context, message OutboxMessage) error
 }
+
+// OutboxMessageBatchPublisher is implemented by publishers that can send several messages in one request.
+//
+// PublishBatch returns nil when every message was published, a *BatchPublishError when only
+// some of them failed, and any other error when none of them can be considered published.
+type OutboxMessageBatchPublisher interface {
+	OutboxMessagePublisher
+	PublishBatch(ctx context.Context, messages []OutboxMessage) error
+}
+
+// BatchPublishError reports the messages of a batch that could not be published, keyed by message ID.
+type BatchPublishError struct {
+	Errors map[string]error
+}
+
+func (e *BatchPublishError) Error() string {
+	ids := make([]string, 0, len(e.Errors))
+	for id := range e.Errors {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	failures := make([]string, 0, len(ids))
+	for _, id := range ids {
+		failures = append(failures, fmt.Sprintf("%s: %v", id, e.Errors[id]))
+	}
+
+	return fmt.Sprintf("failed to publish %d message(s): %s", len(ids), strings.Join(failures, "; "))
+}
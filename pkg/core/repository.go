@@ -12,3 +12,33 @@ type OutboxMessageRepository interface {
 	MarkMessageAsFailed(ctx context.Context, id string, shouldIncrementAttempts bool) error
 	MarkMessageForRetry(ctx context.Context, id string, delay time.Duration, shouldIncrementAttempts bool) error
 }
+
+// OutboxDeliveryRepository tracks which destinations of a fan-out publisher already received
+// a message, so a retry only re-sends the message to the destinations that failed.
+type OutboxDeliveryRepository interface {
+	FetchDeliveredDestinations(ctx context.Context, messageID string) ([]string, error)
+	MarkDestinationAsDelivered(ctx context.Context, messageID string, destination string) error
+}
+
+// OutboxRetentionRepository removes finished messages, so a janitor can keep the outbox small.
+// Both methods remove at most limit messages of the given status created before createdBefore,
+// and return how many they removed.
+type OutboxRetentionRepository interface {
+	DeleteMessages(ctx context.Context, status MessageStatus, createdBefore time.Time, limit uint32) (int, error)
+	ArchiveMessages(ctx context.Context, status MessageStatus, createdBefore time.Time, limit uint32) (int, error)
+}
+
+// OutboxPartitionDropper maintains an outbox partitioned by creation time. DropPartitions drops
+// the partitions that only hold sent or failed messages created before createdBefore, and
+// returns how many it dropped.
+type OutboxPartitionDropper interface {
+	DropPartitions(ctx context.Context, createdBefore time.Time) (int, error)
+}
+
+// OutboxMessageScheduler is implemented by repositories that can change scheduled messages, which
+// are saved with a future AvailableAt. Both methods only apply to pending messages and report
+// false for messages that are unknown, being dispatched, sent or failed.
+type OutboxMessageScheduler interface {
+	RescheduleMessage(ctx context.Context, id string, availableAt time.Time) (bool, error)
+	CancelMessage(ctx context.Context, id string) (bool, error)
+}
@@ -0,0 +1,217 @@
+// Package cdc dispatches outbox messages as they are inserted, by consuming the outbox table
+// from a Postgres logical replication slot instead of polling it.
+package cdc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-transactional-outbox/pkg/core"
+	"go-transactional-outbox/pkg/dispatcher"
+	"strings"
+	"time"
+
+	"github.com/jackc/pglogrepl"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgproto3"
+)
+
+// duplicateObjectCode is the SQLSTATE of creating a slot or publication that already exists.
+const duplicateObjectCode = "42710"
+
+// Repository is the outbox repository the replication dispatcher claims and settles messages
+// with. It is implemented by postgresql.PostgresRepository.
+type Repository interface {
+	core.OutboxMessageRepository
+	ClaimMessage(ctx context.Context, id string) (core.OutboxMessage, bool, error)
+}
+
+type ReplicationConfigs struct {
+	Dispatcher      dispatcher.DispatcherConfigs // Retry behaviour for messages that fail to publish.
+	TableName       string                       // Outbox table whose inserts are dispatched.
+	SlotName        string                       // Logical replication slot, created on first run.
+	PublicationName string                       // Publication of the outbox table's inserts, created on first run.
+	StatusInterval  time.Duration                // How often the handled WAL position is reported to the server.
+}
+
+func DefaultReplicationConfigs() ReplicationConfigs {
+	return ReplicationConfigs{
+		Dispatcher:      dispatcher.DefaultDispatcherConfigs(),
+		TableName:       "outbox",
+		SlotName:        "outbox_dispatcher",
+		PublicationName: "outbox_dispatcher",
+		StatusInterval:  10 * time.Second,
+	}
+}
+
+// ReplicationDispatcher publishes messages as their inserts arrive through logical replication
+// (the pgoutput plugin, which needs wal_level=logical).
+//
+// Every inserted message is claimed in the repository before it is published, so it is never
+// published by both this dispatcher and a polling one. Failed messages take the usual retry path
+// through MarkMessageForRetry, so a polling dispatcher must run alongside to publish retries and
+// scheduled messages; its poll interval can be long. A WAL position is only confirmed once every
+// message before it was settled, so after a crash the inserts since the last confirmed position
+// are received again and messages that were already handled are skipped when claiming them fails.
+type ReplicationDispatcher struct {
+	dsn        string
+	repository Repository
+	publisher  core.OutboxMessagePublisher
+	dispatcher *dispatcher.DefaultOutboxMessageDispatcher
+	configs    ReplicationConfigs
+}
+
+func NewReplicationDispatcher(dsn string, repository Repository, publisher core.OutboxMessagePublisher, configs ReplicationConfigs) *ReplicationDispatcher {
+	return &ReplicationDispatcher{
+		dsn:        dsn,
+		repository: repository,
+		publisher:  publisher,
+		dispatcher: dispatcher.NewDefaultOutboxMessageDispatcher(repository, publisher, configs.Dispatcher),
+		configs:    configs,
+	}
+}
+
+// Run consumes the replication slot until ctx is done or the replication connection fails. It
+// returns the error that stopped it; callers restart it to reconnect, resuming from the last
+// confirmed position.
+func (d *ReplicationDispatcher) Run(ctx context.Context) error {
+	config, err := pgconn.ParseConfig(d.dsn)
+	if err != nil {
+		return fmt.Errorf("failed to parse replication connection string: %w", err)
+	}
+
+	config.RuntimeParams["replication"] = "database"
+
+	conn, err := pgconn.ConnectConfig(ctx, config)
+	if err != nil {
+		return fmt.Errorf("failed to open replication connection: %w", err)
+	}
+
+	defer conn.Close(context.Background())
+
+	if err := d.setup(ctx, conn); err != nil {
+		return err
+	}
+
+	if err := d.startReplication(ctx, conn); err != nil {
+		return err
+	}
+
+	s := newStream(d.configs.TableName, d.dispatchMessage)
+
+	// The server may discard the WAL before the reported position, so only positions up to which
+	// every message was handled are reported.
+	sendStatus := func() error {
+		err := pglogrepl.SendStandbyStatusUpdate(ctx, conn, pglogrepl.StandbyStatusUpdate{WALWritePosition: s.confirmedLSN})
+		if err != nil {
+			return fmt.Errorf("failed to send standby status: %w", err)
+		}
+		return nil
+	}
+
+	nextStatusAt := time.Now().Add(d.configs.StatusInterval)
+
+	for {
+		if !time.Now().Before(nextStatusAt) {
+			if err := sendStatus(); err != nil {
+				return err
+			}
+			nextStatusAt = time.Now().Add(d.configs.StatusInterval)
+		}
+
+		receiveCtx, cancel := context.WithDeadline(ctx, nextStatusAt)
+		msg, err := conn.ReceiveMessage(receiveCtx)
+		cancel()
+
+		if err != nil {
+			if ctx.Err() != nil {
+				_ = sendStatus()
+				return ctx.Err()
+			}
+			if pgconn.Timeout(err) {
+				continue
+			}
+			return fmt.Errorf("failed to receive replication message: %w", err)
+		}
+
+		switch msg := msg.(type) {
+		case *pgproto3.CopyData:
+			replyRequested, err := s.handleCopyData(ctx, msg.Data)
+			if err != nil {
+				return err
+			}
+			if replyRequested {
+				nextStatusAt = time.Now()
+			}
+		case *pgproto3.ErrorResponse:
+			return fmt.Errorf("replication failed: %w", pgconn.ErrorResponseToPgError(msg))
+		}
+	}
+}
+
+// setup creates the publication and the replication slot unless they exist. Inserts into a
+// partitioned outbox are published under the outbox's name instead of the partition's, so they
+// are recognized as inserts into the outbox.
+func (d *ReplicationDispatcher) setup(ctx context.Context, conn *pgconn.PgConn) error {
+	publication := fmt.Sprintf(
+		"CREATE PUBLICATION %s FOR TABLE %s WITH (publish = 'insert', publish_via_partition_root = true)",
+		quoteIdentifier(d.configs.PublicationName),
+		quoteIdentifier(d.configs.TableName),
+	)
+
+	_, err := conn.Exec(ctx, publication).ReadAll()
+	if err != nil && !isDuplicateObject(err) {
+		return fmt.Errorf("failed to set up logical replication: %w", err)
+	}
+
+	_, err = pglogrepl.CreateReplicationSlot(ctx, conn, quoteIdentifier(d.configs.SlotName), "pgoutput", pglogrepl.CreateReplicationSlotOptions{
+		Mode: pglogrepl.LogicalReplication,
+	})
+	if err != nil && !isDuplicateObject(err) {
+		return fmt.Errorf("failed to set up logical replication: %w", err)
+	}
+
+	return nil
+}
+
+// startReplication switches the connection to streaming, from the slot's confirmed position.
+func (d *ReplicationDispatcher) startReplication(ctx context.Context, conn *pgconn.PgConn) error {
+	err := pglogrepl.StartReplication(ctx, conn, quoteIdentifier(d.configs.SlotName), 0, pglogrepl.StartReplicationOptions{
+		Mode: pglogrepl.LogicalReplication,
+		PluginArgs: []string{
+			"proto_version '1'",
+			"publication_names '" + strings.ReplaceAll(d.configs.PublicationName, "'", "''") + "'",
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start replication: %w", err)
+	}
+
+	return nil
+}
+
+// dispatchMessage publishes an inserted message, unless a polling dispatcher claimed it first or
+// it is scheduled for later.
+func (d *ReplicationDispatcher) dispatchMessage(ctx context.Context, id string) error {
+	message, ok, err := d.repository.ClaimMessage(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to claim message %s: %w", id, err)
+	}
+
+	if !ok {
+		return nil
+	}
+
+	// A message that could not be settled stops the stream before its commit is confirmed, so it
+	// is received again once the dispatcher restarts.
+	return d.dispatcher.HandlePublishResult(ctx, message, d.publisher.Publish(ctx, message))
+}
+
+func isDuplicateObject(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == duplicateObjectCode
+}
+
+func quoteIdentifier(identifier string) string {
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
@@ -0,0 +1,341 @@
+package cdc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"go-transactional-outbox/pkg/core"
+	"go-transactional-outbox/pkg/publisher/publishertest"
+	"go-transactional-outbox/pkg/repository/memory"
+	"go-transactional-outbox/pkg/repository/postgresql"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pglogrepl"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// claimingRepository adds ClaimMessage to the in-memory repository.
+type claimingRepository struct {
+	*memory.MemoryRepository
+	mu      sync.Mutex
+	claimed map[string]bool
+}
+
+func newClaimingRepository() *claimingRepository {
+	return &claimingRepository{
+		MemoryRepository: memory.NewMemoryRepository(),
+		claimed:          make(map[string]bool),
+	}
+}
+
+func (r *claimingRepository) ClaimMessage(ctx context.Context, id string) (core.OutboxMessage, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	message, ok := r.Message(id)
+	if !ok || message.Status != core.MessageStatusPending || r.claimed[id] {
+		return core.OutboxMessage{}, false, nil
+	}
+
+	r.claimed[id] = true
+	message.Status = core.MessageStatusProcessing
+
+	return message, true, nil
+}
+
+func newTestDispatcher(repository Repository, publisher core.OutboxMessagePublisher) (*ReplicationDispatcher, *stream) {
+	d := NewReplicationDispatcher("", repository, publisher, DefaultReplicationConfigs())
+	return d, newStream(d.configs.TableName, d.dispatchMessage)
+}
+
+// insertTransaction replays an insert of the given message IDs into the outbox, committed at endLSN.
+func insertTransaction(t *testing.T, s *stream, endLSN pglogrepl.LSN, ids ...string) {
+	ctx := context.Background()
+
+	for _, data := range [][]byte{
+		xLogData(endLSN, relationMessage(1, "public", "outbox", "id", "payload")),
+		xLogData(endLSN, beginMessage()),
+	} {
+		_, err := s.handleCopyData(ctx, data)
+		require.NoError(t, err)
+	}
+
+	for _, id := range ids {
+		_, err := s.handleCopyData(ctx, xLogData(endLSN, insertMessage(1, stringPtr(id), stringPtr("Payload"))))
+		require.NoError(t, err)
+	}
+
+	_, err := s.handleCopyData(ctx, xLogData(endLSN, commitMessage(endLSN)))
+	require.NoError(t, err)
+}
+
+func TestStream_PublishesInsertedMessages(t *testing.T) {
+	ctx := context.Background()
+	repository := newClaimingRepository()
+	publisher := publishertest.NewPublisher()
+	_, s := newTestDispatcher(repository, publisher)
+
+	require.NoError(t, repository.SaveMessage(ctx, core.OutboxMessage{ID: "msg-1", Payload: "Payload 1", Status: core.MessageStatusPending}))
+	require.NoError(t, repository.SaveMessage(ctx, core.OutboxMessage{ID: "msg-2", Payload: "Payload 2", Status: core.MessageStatusPending}))
+
+	insertTransaction(t, s, 100, "msg-1", "msg-2")
+
+	publisher.AssertPublishedInOrder(t, "msg-1", "msg-2")
+	assert.Equal(t, pglogrepl.LSN(100), s.confirmedLSN)
+
+	message, _ := repository.Message("msg-1")
+	assert.Equal(t, core.MessageStatusSent, message.Status)
+}
+
+func TestStream_RetriesFailedMessages(t *testing.T) {
+	ctx := context.Background()
+	repository := newClaimingRepository()
+	publisher := publishertest.NewPublisher()
+	publisher.FailMessage("msg-1", errors.New("broker unavailable"))
+	_, s := newTestDispatcher(repository, publisher)
+
+	require.NoError(t, repository.SaveMessage(ctx, core.OutboxMessage{ID: "msg-1", Payload: "Payload", Status: core.MessageStatusPending}))
+
+	insertTransaction(t, s, 100, "msg-1")
+
+	message, _ := repository.Message("msg-1")
+	assert.Equal(t, core.MessageStatusPending, message.Status, "Failed messages must take the retry path")
+	assert.EqualValues(t, 1, message.Attempts)
+	assert.Equal(t, pglogrepl.LSN(100), s.confirmedLSN)
+}
+
+func TestStream_SkipsMessagesClaimedElsewhere(t *testing.T) {
+	ctx := context.Background()
+	repository := newClaimingRepository()
+	publisher := publishertest.NewPublisher()
+	_, s := newTestDispatcher(repository, publisher)
+
+	require.NoError(t, repository.SaveMessage(ctx, core.OutboxMessage{ID: "msg-1", Payload: "Payload", Status: core.MessageStatusPending}))
+	_, err := repository.FetchPendingMessages(ctx, 10, 60)
+	require.NoError(t, err)
+
+	insertTransaction(t, s, 100, "msg-1", "unknown")
+
+	publisher.AssertNotPublished(t, "msg-1")
+	assert.Equal(t, pglogrepl.LSN(100), s.confirmedLSN)
+}
+
+func TestStream_ConfirmsOnlyCompletedTransactions(t *testing.T) {
+	ctx := context.Background()
+	_, s := newTestDispatcher(newClaimingRepository(), publishertest.NewPublisher())
+
+	replyRequested, err := s.handleCopyData(ctx, keepalive(50, true))
+	require.NoError(t, err)
+	assert.True(t, replyRequested)
+	assert.Equal(t, pglogrepl.LSN(50), s.confirmedLSN, "Idle keepalives confirm the server position")
+
+	_, err = s.handleCopyData(ctx, xLogData(60, beginMessage()))
+	require.NoError(t, err)
+
+	_, err = s.handleCopyData(ctx, keepalive(80, false))
+	require.NoError(t, err)
+	assert.Equal(t, pglogrepl.LSN(50), s.confirmedLSN, "Positions inside a transaction must not be confirmed")
+
+	_, err = s.handleCopyData(ctx, xLogData(60, commitMessage(90)))
+	require.NoError(t, err)
+	assert.Equal(t, pglogrepl.LSN(90), s.confirmedLSN)
+}
+
+func TestStream_IgnoresOtherTables(t *testing.T) {
+	ctx := context.Background()
+	publisher := publishertest.NewPublisher()
+	_, s := newTestDispatcher(newClaimingRepository(), publisher)
+
+	_, err := s.handleCopyData(ctx, xLogData(10, relationMessage(2, "public", "orders", "id")))
+	require.NoError(t, err)
+
+	_, err = s.handleCopyData(ctx, xLogData(10, insertMessage(2, stringPtr("order-1"))))
+	require.NoError(t, err)
+
+	assert.Empty(t, publisher.Calls())
+
+	_, err = s.handleCopyData(ctx, xLogData(10, insertMessage(3, stringPtr("msg-1"))))
+	assert.Error(t, err, "Inserts into relations that were never described are rejected")
+}
+
+func TestStream_ClaimError(t *testing.T) {
+	ctx := context.Background()
+	claimErr := errors.New("connection reset")
+	d := NewReplicationDispatcher("", &failingRepository{claimingRepository: newClaimingRepository(), err: claimErr}, publishertest.NewPublisher(), DefaultReplicationConfigs())
+	s := newStream(d.configs.TableName, d.dispatchMessage)
+
+	_, err := s.handleCopyData(ctx, xLogData(10, relationMessage(1, "public", "outbox", "id")))
+	require.NoError(t, err)
+
+	_, err = s.handleCopyData(ctx, xLogData(10, insertMessage(1, stringPtr("msg-1"))))
+	assert.ErrorIs(t, err, claimErr)
+}
+
+type failingRepository struct {
+	*claimingRepository
+	err error
+}
+
+func (r *failingRepository) ClaimMessage(ctx context.Context, id string) (core.OutboxMessage, bool, error) {
+	return core.OutboxMessage{}, false, r.err
+}
+
+// unsettledRepository fails to record publish outcomes.
+type unsettledRepository struct {
+	*claimingRepository
+	err error
+}
+
+func (r *unsettledRepository) MarkMessageAsSent(ctx context.Context, id string, shouldIncrementAttempts bool) error {
+	return r.err
+}
+
+func (r *unsettledRepository) MarkMessageForRetry(ctx context.Context, id string, delay time.Duration, shouldIncrementAttempts bool) error {
+	return r.err
+}
+
+func TestStream_StopsBeforeConfirmingUnsettledMessages(t *testing.T) {
+	for _, publishErr := range []error{nil, errors.New("broker unavailable")} {
+		t.Run(fmt.Sprint(publishErr), func(t *testing.T) {
+			ctx := context.Background()
+			settleErr := errors.New("connection reset")
+			repository := &unsettledRepository{claimingRepository: newClaimingRepository(), err: settleErr}
+			publisher := publishertest.NewPublisher()
+			if publishErr != nil {
+				publisher.FailMessage("msg-1", publishErr)
+			}
+			d := NewReplicationDispatcher("", repository, publisher, DefaultReplicationConfigs())
+			s := newStream(d.configs.TableName, d.dispatchMessage)
+
+			require.NoError(t, repository.SaveMessage(ctx, core.OutboxMessage{ID: "msg-1", Payload: "Payload", Status: core.MessageStatusPending}))
+
+			insertTransaction(t, s, 100)
+
+			_, err := s.handleCopyData(ctx, xLogData(200, beginMessage()))
+			require.NoError(t, err)
+
+			_, err = s.handleCopyData(ctx, xLogData(200, insertMessage(1, stringPtr("msg-1"), stringPtr("Payload"))))
+			assert.ErrorIs(t, err, settleErr)
+
+			_, err = s.handleCopyData(ctx, keepalive(300, false))
+			require.NoError(t, err)
+			assert.Equal(t, pglogrepl.LSN(100), s.confirmedLSN, "Positions after an unsettled message must not be confirmed")
+		})
+	}
+}
+
+// openReplicationTestDB connects to the test database, and skips the test unless it runs with
+// wal_level=logical (see docker-compose.yml).
+func openReplicationTestDB(t *testing.T) (*sql.DB, string) {
+	dsn := os.Getenv("OUTBOX_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		dsn = "host=localhost port=5432 user=postgres password=secret dbname=testdb sslmode=disable"
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	var walLevel string
+	if err := db.QueryRowContext(ctx, "SHOW wal_level").Scan(&walLevel); err != nil {
+		t.Skipf("test database is unavailable: %v", err)
+	}
+	if walLevel != "logical" {
+		t.Skipf("test database runs with wal_level=%s", walLevel)
+	}
+
+	return db, dsn
+}
+
+// testReplication saves a message and checks that the replication dispatcher publishes it.
+func testReplication(t *testing.T, db *sql.DB, dsn string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	configs := DefaultReplicationConfigs()
+	configs.SlotName = "outbox_dispatcher_test"
+	configs.PublicationName = "outbox_dispatcher_test"
+
+	t.Cleanup(func() {
+		_, _ = db.Exec("SELECT pg_drop_replication_slot($1)", configs.SlotName)
+		_, _ = db.Exec("DROP PUBLICATION IF EXISTS outbox_dispatcher_test")
+		_, _ = db.Exec("DELETE FROM outbox WHERE id = 'cdc-1'")
+	})
+
+	repository := postgresql.NewPostgresRepository(db)
+	publisher := publishertest.NewPublisher()
+	d := NewReplicationDispatcher(dsn, repository, publisher, configs)
+
+	runCtx, stop := context.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() { done <- d.Run(runCtx) }()
+
+	// The slot only streams changes made after it was created.
+	require.Eventually(t, func() bool {
+		var exists bool
+		_ = db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM pg_replication_slots WHERE slot_name = $1 AND active)", configs.SlotName).Scan(&exists)
+		return exists
+	}, 10*time.Second, 50*time.Millisecond)
+
+	require.NoError(t, repository.SaveMessage(ctx, core.OutboxMessage{ID: "cdc-1", Payload: "Payload", Status: core.MessageStatusPending}))
+
+	require.Eventually(t, func() bool {
+		return len(publisher.Published()) == 1
+	}, 10*time.Second, 50*time.Millisecond)
+
+	stop()
+	assert.ErrorIs(t, <-done, context.Canceled)
+
+	var status string
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT status FROM outbox WHERE id = 'cdc-1'").Scan(&status))
+	assert.Equal(t, string(core.MessageStatusSent), status)
+}
+
+func TestReplicationDispatcher_Run(t *testing.T) {
+	db, dsn := openReplicationTestDB(t)
+
+	require.NoError(t, postgresql.Migrate(context.Background(), db))
+
+	testReplication(t, db, dsn)
+}
+
+// TestReplicationDispatcher_Run_PartitionedOutbox migrates a partitioned outbox in its own schema,
+// which both connections use through their search_path.
+func TestReplicationDispatcher_Run_PartitionedOutbox(t *testing.T) {
+	db, dsn := openReplicationTestDB(t)
+
+	const schema = "outbox_cdc_partitioned_test"
+
+	_, err := db.Exec("DROP SCHEMA IF EXISTS " + schema + " CASCADE; CREATE SCHEMA " + schema)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _, _ = db.Exec("DROP SCHEMA IF EXISTS " + schema + " CASCADE") })
+
+	if strings.Contains(dsn, "://") {
+		separator := "?"
+		if strings.Contains(dsn, "?") {
+			separator = "&"
+		}
+		dsn += separator + "search_path=" + schema
+	} else {
+		dsn += " search_path=" + schema
+	}
+
+	partitionedDB, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { partitionedDB.Close() })
+
+	require.NoError(t, postgresql.MigratePartitioned(context.Background(), partitionedDB, postgresql.DefaultTimePartitionConfigs()))
+
+	testReplication(t, partitionedDB, dsn)
+}
@@ -0,0 +1,113 @@
+package cdc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pglogrepl"
+)
+
+var errEmptyMessage = errors.New("replication message is empty")
+
+// stream follows the replication stream of the outbox table, decoded with pglogrepl from the
+// pgoutput plugin's protocol version 1, and tracks the WAL position up to which every message was
+// handled. Other messages than inserts, such as updates and deletes, are not needed by the
+// dispatcher and are skipped.
+type stream struct {
+	tableName     string
+	handle        func(ctx context.Context, id string) error
+	relations     map[uint32]*pglogrepl.RelationMessage
+	inTransaction bool
+	confirmedLSN  pglogrepl.LSN
+}
+
+func newStream(tableName string, handle func(ctx context.Context, id string) error) *stream {
+	return &stream{
+		tableName: tableName,
+		handle:    handle,
+		relations: make(map[uint32]*pglogrepl.RelationMessage),
+	}
+}
+
+// handleCopyData handles one message of the replication stream and reports whether the server
+// asked for a status update.
+func (s *stream) handleCopyData(ctx context.Context, data []byte) (bool, error) {
+	if len(data) == 0 {
+		return false, errEmptyMessage
+	}
+
+	switch data[0] {
+	case pglogrepl.PrimaryKeepaliveMessageByteID:
+		keepalive, err := pglogrepl.ParsePrimaryKeepaliveMessage(data[1:])
+		if err != nil {
+			return false, fmt.Errorf("failed to parse keepalive: %w", err)
+		}
+
+		// Outside a transaction everything up to the server's position was handled, which lets
+		// the server discard WAL of unrelated tables.
+		if !s.inTransaction && keepalive.ServerWALEnd > s.confirmedLSN {
+			s.confirmedLSN = keepalive.ServerWALEnd
+		}
+
+		return keepalive.ReplyRequested, nil
+	case pglogrepl.XLogDataByteID:
+		xLogData, err := pglogrepl.ParseXLogData(data[1:])
+		if err != nil {
+			return false, fmt.Errorf("failed to parse WAL data: %w", err)
+		}
+
+		return false, s.handleWALData(ctx, xLogData.WALData)
+	}
+
+	return false, nil
+}
+
+func (s *stream) handleWALData(ctx context.Context, data []byte) error {
+	if len(data) == 0 {
+		return errEmptyMessage
+	}
+
+	message, err := pglogrepl.Parse(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse WAL data: %w", err)
+	}
+
+	switch message := message.(type) {
+	case *pglogrepl.BeginMessage:
+		s.inTransaction = true
+	case *pglogrepl.CommitMessage:
+		s.inTransaction = false
+		s.confirmedLSN = message.TransactionEndLSN
+	case *pglogrepl.RelationMessage:
+		s.relations[message.RelationID] = message
+	case *pglogrepl.InsertMessage:
+		return s.handleInsert(ctx, message)
+	}
+
+	return nil
+}
+
+// handleInsert handles an inserted message by its ID, which pgoutput sends in the text format.
+func (s *stream) handleInsert(ctx context.Context, insert *pglogrepl.InsertMessage) error {
+	rel, ok := s.relations[insert.RelationID]
+	if !ok {
+		return fmt.Errorf("insert into unknown relation %d", insert.RelationID)
+	}
+
+	if rel.RelationName != s.tableName {
+		return nil
+	}
+
+	for i, column := range rel.Columns {
+		if column.Name != "id" || i >= len(insert.Tuple.Columns) {
+			continue
+		}
+
+		if value := insert.Tuple.Columns[i]; value.DataType == pglogrepl.TupleDataTypeText {
+			return s.handle(ctx, string(value.Data))
+		}
+	}
+
+	return fmt.Errorf("insert into %s.%s has no id", rel.Namespace, rel.RelationName)
+}
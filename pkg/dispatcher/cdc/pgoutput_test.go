@@ -0,0 +1,122 @@
+package cdc
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/jackc/pglogrepl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// encoder builds protocol messages the way the server sends them.
+type encoder []byte
+
+func (e encoder) uint8(v uint8) encoder   { return append(e, v) }
+func (e encoder) uint16(v uint16) encoder { return binary.BigEndian.AppendUint16(e, v) }
+func (e encoder) uint32(v uint32) encoder { return binary.BigEndian.AppendUint32(e, v) }
+func (e encoder) uint64(v uint64) encoder { return binary.BigEndian.AppendUint64(e, v) }
+func (e encoder) string(v string) encoder { return append(append(e, v...), 0) }
+
+func relationMessage(relationID uint32, namespace string, name string, columns ...string) []byte {
+	e := encoder{byte(pglogrepl.MessageTypeRelation)}.uint32(relationID).string(namespace).string(name).uint8('d').uint16(uint16(len(columns)))
+	for _, column := range columns {
+		e = e.uint8(0).string(column).uint32(25).uint32(0xFFFFFFFF)
+	}
+	return e
+}
+
+// insertMessage encodes an insert with text values; nil values are null.
+func insertMessage(relationID uint32, values ...*string) []byte {
+	e := encoder{byte(pglogrepl.MessageTypeInsert)}.uint32(relationID).uint8('N').uint16(uint16(len(values)))
+	for _, value := range values {
+		if value == nil {
+			e = e.uint8('n')
+			continue
+		}
+		e = e.uint8('t').uint32(uint32(len(*value)))
+		e = append(e, *value...)
+	}
+	return e
+}
+
+func beginMessage() []byte {
+	return encoder{byte(pglogrepl.MessageTypeBegin)}.uint64(0).uint64(0).uint32(1)
+}
+
+func commitMessage(endLSN pglogrepl.LSN) []byte {
+	return encoder{byte(pglogrepl.MessageTypeCommit)}.uint8(0).uint64(uint64(endLSN) - 1).uint64(uint64(endLSN)).uint64(0)
+}
+
+func xLogData(walStart pglogrepl.LSN, message []byte) []byte {
+	return append(encoder{pglogrepl.XLogDataByteID}.uint64(uint64(walStart)).uint64(0).uint64(0), message...)
+}
+
+func keepalive(serverWALEnd pglogrepl.LSN, replyRequested bool) []byte {
+	reply := uint8(0)
+	if replyRequested {
+		reply = 1
+	}
+	return encoder{pglogrepl.PrimaryKeepaliveMessageByteID}.uint64(uint64(serverWALEnd)).uint64(0).uint8(reply)
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+// recordingStream returns a stream of the outbox table that records the handled message IDs.
+func recordingStream() (*stream, *[]string) {
+	var handled []string
+	s := newStream("outbox", func(ctx context.Context, id string) error {
+		handled = append(handled, id)
+		return nil
+	})
+	return s, &handled
+}
+
+func TestStream_HandlesInsertedIDs(t *testing.T) {
+	ctx := context.Background()
+	s, handled := recordingStream()
+
+	_, err := s.handleCopyData(ctx, xLogData(10, relationMessage(7, "public", "outbox", "payload", "id")))
+	require.NoError(t, err)
+
+	_, err = s.handleCopyData(ctx, xLogData(10, insertMessage(7, nil, stringPtr("msg-1"))))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"msg-1"}, *handled)
+
+	_, err = s.handleCopyData(ctx, xLogData(10, insertMessage(7, stringPtr("Payload"), nil)))
+	assert.EqualError(t, err, "insert into public.outbox has no id")
+}
+
+func TestStream_RejectsMalformedMessages(t *testing.T) {
+	ctx := context.Background()
+	s, _ := recordingStream()
+
+	_, err := s.handleCopyData(ctx, nil)
+	assert.ErrorIs(t, err, errEmptyMessage)
+
+	_, err = s.handleCopyData(ctx, []byte{pglogrepl.PrimaryKeepaliveMessageByteID, 1, 2, 3})
+	assert.ErrorContains(t, err, "failed to parse keepalive")
+
+	_, err = s.handleCopyData(ctx, []byte{pglogrepl.XLogDataByteID, 1, 2, 3})
+	assert.ErrorContains(t, err, "failed to parse WAL data")
+
+	_, err = s.handleCopyData(ctx, xLogData(10, nil))
+	assert.ErrorIs(t, err, errEmptyMessage)
+
+	_, err = s.handleCopyData(ctx, xLogData(10, commitMessage(20)[:10]))
+	assert.ErrorContains(t, err, "failed to parse WAL data")
+}
+
+func TestStream_SkipsOtherStreamMessages(t *testing.T) {
+	s, handled := recordingStream()
+
+	replyRequested, err := s.handleCopyData(context.Background(), []byte{'x', 1, 2})
+
+	require.NoError(t, err)
+	assert.False(t, replyRequested)
+	assert.Empty(t, *handled)
+}
@@ -1,11 +1,19 @@
 package dispatcher
 
-import "time"
+import (
+	"go-transactional-outbox/pkg/core"
+	"time"
+)
 
 type DispatcherConfigs struct {
 	Retry                 RetryConfigs
-	FetchLimit            uint32 // Maximum number of messages fetched per batch.
-	ProcessingLockTimeout uint32 // Maximum duration (in seconds) a message remains locked for processing.
+	FetchLimit            uint32                     // Maximum number of messages fetched per batch.
+	ProcessingLockTimeout uint32                     // Maximum duration (in seconds) a message remains locked for processing.
+	PollInterval          time.Duration              // Delay between fetches of the run loop.
+	Notifier              core.OutboxMessageNotifier // Optional. Wakes up the run loop as soon as messages are saved.
+	LeaderElector         core.OutboxLeaderElector   // Optional. Makes the run loop dispatch only while it is the leader.
+	PartitionLeaser       core.OutboxPartitionLeaser // Optional. Runs alongside the run loop to assign the partitions the repository fetches.
+	Observer              core.OutboxObserver        // Optional. Notified of every published, retried and failed message.
 }
 
 func DefaultDispatcherConfigs() DispatcherConfigs {
@@ -13,6 +21,31 @@ func DefaultDispatcherConfigs() DispatcherConfigs {
 		Retry:                 DefaultRetryConfigs(),
 		FetchLimit:            100,
 		ProcessingLockTimeout: 30,
+		PollInterval:          1 * time.Second,
+	}
+}
+
+type JanitorConfigs struct {
+	SentRetention   time.Duration       // Age after which sent messages are removed. Zero keeps them.
+	FailedRetention time.Duration       // Age after which failed messages are removed. Zero keeps them.
+	Archive         bool                // Moves messages to the archive instead of deleting them.
+	BatchSize       uint32              // Maximum number of messages removed at once.
+	BatchPause      time.Duration       // Pause between batches, to limit the load on the database.
+	Interval        time.Duration       // Delay between cleanups of the run loop.
+	Observer        core.OutboxObserver // Optional. Notified of the number of removed messages and dropped partitions.
+
+	// Partitions optionally drops the partitions of an outbox partitioned by creation time once
+	// both retention periods passed. It is not used when messages are archived.
+	Partitions core.OutboxPartitionDropper
+}
+
+func DefaultJanitorConfigs() JanitorConfigs {
+	return JanitorConfigs{
+		SentRetention:   7 * 24 * time.Hour,
+		FailedRetention: 30 * 24 * time.Hour,
+		BatchSize:       1000,
+		BatchPause:      100 * time.Millisecond,
+		Interval:        1 * time.Hour,
 	}
 }
 
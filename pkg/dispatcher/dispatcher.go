@@ -2,8 +2,10 @@ package dispatcher
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"go-transactional-outbox/pkg/core"
+	"time"
 )
 
 type DefaultOutboxMessageDispatcher struct {
@@ -12,39 +14,220 @@ type DefaultOutboxMessageDispatcher struct {
 	configs    DispatcherConfigs
 }
 
+func NewDefaultOutboxMessageDispatcher(repository core.OutboxMessageRepository, publisher core.OutboxMessagePublisher, configs DispatcherConfigs) *DefaultOutboxMessageDispatcher {
+	return &DefaultOutboxMessageDispatcher{
+		repository: repository,
+		publisher:  publisher,
+		configs:    configs,
+	}
+}
+
+// Run dispatches messages until ctx is done, then returns the context's error. It fetches every
+// PollInterval and, when a Notifier is configured, as soon as it is notified. Polling continues
+// while notifications are enabled, so messages are still dispatched when notifications are lost
+// or the notifier stops. Failed dispatches do not stop the loop; the next fetch retries them.
+//
+// With a LeaderElector, Run only dispatches while it is the leader. Standby dispatchers try to
+// take over every PollInterval, so one of them resumes dispatching shortly after the leader is gone.
+//
+// With a PartitionLeaser, Run also runs the leaser and only returns once it gave its partitions up.
+func (d *DefaultOutboxMessageDispatcher) Run(ctx context.Context) error {
+	if d.configs.PartitionLeaser != nil {
+		stop := d.runPartitionLeaser(ctx)
+		defer stop()
+	}
+
+	if d.configs.LeaderElector == nil {
+		return d.run(ctx)
+	}
+
+	for {
+		leaderCtx, release, err := d.configs.LeaderElector.AcquireLeadership(ctx)
+		if err == nil {
+			_ = d.run(leaderCtx)
+			release()
+		}
+
+		if err := sleep(ctx, d.pollInterval()); err != nil {
+			return err
+		}
+	}
+}
+
+func (d *DefaultOutboxMessageDispatcher) run(ctx context.Context) error {
+	ticker := time.NewTicker(d.pollInterval())
+	defer ticker.Stop()
+
+	var notifications <-chan struct{}
+	if d.configs.Notifier != nil {
+		notifications = d.configs.Notifier.Notifications()
+	}
+
+	for {
+		d.dispatchAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		case _, ok := <-notifications:
+			if !ok {
+				notifications = nil
+			}
+		}
+	}
+}
+
+// runPartitionLeaser runs the partition leaser in the background and returns a function that
+// stops it and waits for it to return.
+func (d *DefaultOutboxMessageDispatcher) runPartitionLeaser(ctx context.Context) func() {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		_ = d.configs.PartitionLeaser.Run(ctx)
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+func (d *DefaultOutboxMessageDispatcher) pollInterval() time.Duration {
+	if d.configs.PollInterval <= 0 {
+		return DefaultDispatcherConfigs().PollInterval
+	}
+
+	return d.configs.PollInterval
+}
+
 func (d *DefaultOutboxMessageDispatcher) Dispatch(ctx context.Context) error {
+	_, err := d.dispatch(ctx)
+
+	return err
+}
+
+// dispatchAll dispatches until a fetch returns less than a full batch, so a burst of messages
+// does not wait for the next poll.
+func (d *DefaultOutboxMessageDispatcher) dispatchAll(ctx context.Context) {
+	for ctx.Err() == nil {
+		fetched, err := d.dispatch(ctx)
+		if err != nil || fetched == 0 || uint32(fetched) < d.configs.FetchLimit {
+			return
+		}
+	}
+}
+
+// dispatch publishes one batch of pending messages and returns the number of fetched messages.
+// Messages that could not be settled are locked until the processing lock times out, and are then
+// fetched again.
+func (d *DefaultOutboxMessageDispatcher) dispatch(ctx context.Context) (int, error) {
 	messages, err := d.repository.FetchPendingMessages(ctx, d.configs.FetchLimit, d.configs.ProcessingLockTimeout)
 	if err != nil {
-		return fmt.Errorf("failed to fetch messages: %w", err)
+		return 0, fmt.Errorf("failed to fetch messages: %w", err)
 	}
 
+	var publishable []core.OutboxMessage
+	var errs []error
+
 	for _, message := range messages {
 		if message.GetRetryAttempts() >= d.configs.Retry.MaxRetryAttempts {
-			_ = d.repository.MarkMessageAsFailed(ctx, message.ID, false)
+			if err := d.repository.MarkMessageAsFailed(ctx, message.ID, false); err != nil {
+				errs = append(errs, fmt.Errorf("failed to mark message %s as failed: %w", message.ID, err))
+			}
 			continue
 		}
 
-		err := d.publisher.Publish(ctx, message)
+		publishable = append(publishable, message)
+	}
+
+	// Publishers that support batches get all messages at once; only the failed ones are retried.
+	if batchPublisher, ok := d.publisher.(core.OutboxMessageBatchPublisher); ok && len(publishable) > 0 {
+		err := batchPublisher.PublishBatch(ctx, publishable)
 
-		currentAttempt := message.Attempts + 1
+		var batchErr *core.BatchPublishError
+		isPartialFailure := errors.As(err, &batchErr)
 
-		if err != nil {
-			if message.GetRetryAttempts() < d.configs.Retry.MaxRetryAttempts {
-				_ = d.repository.MarkMessageForRetry(
-					ctx,
-					message.ID,
-					d.calculateRetryDelay(currentAttempt),
-					true,
-				)
-			} else {
-				_ = d.repository.MarkMessageAsFailed(ctx, message.ID, true)
+		for _, message := range publishable {
+			publishErr := err
+			if isPartialFailure {
+				publishErr = batchErr.Errors[message.ID]
 			}
 
-			continue
+			if err := d.HandlePublishResult(ctx, message, publishErr); err != nil {
+				errs = append(errs, err)
+			}
 		}
 
-		_ = d.repository.MarkMessageAsSent(ctx, message.ID, true)
+		return len(messages), errors.Join(errs...)
 	}
 
+	for _, message := range publishable {
+		if err := d.HandlePublishResult(ctx, message, d.publisher.Publish(ctx, message)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return len(messages), errors.Join(errs...)
+}
+
+// HandlePublishResult records the outcome of publishing a message: it is marked as sent, retried
+// with backoff, or marked as failed. It returns the error of recording the outcome, in which case
+// the observer is not notified. It is exported for dispatchers that receive messages by other
+// means than FetchPendingMessages, such as change data capture.
+func (d *DefaultOutboxMessageDispatcher) HandlePublishResult(ctx context.Context, message core.OutboxMessage, err error) error {
+	currentAttempt := message.Attempts + 1
+
+	if err != nil {
+		if !core.IsPermanentError(err) && message.GetRetryAttempts() < d.configs.Retry.MaxRetryAttempts {
+			delay := d.calculateRetryDelay(currentAttempt)
+
+			// The destination's requested delay takes precedence over a shorter backoff.
+			if retryAfter, ok := core.RetryAfter(err); ok && retryAfter > delay {
+				delay = retryAfter
+			}
+
+			if markErr := d.repository.MarkMessageForRetry(ctx, message.ID, delay, true); markErr != nil {
+				return fmt.Errorf("failed to mark message %s for retry: %w", message.ID, markErr)
+			}
+			d.observer().MessageRetried(message, delay, err)
+		} else {
+			if markErr := d.repository.MarkMessageAsFailed(ctx, message.ID, true); markErr != nil {
+				return fmt.Errorf("failed to mark message %s as failed: %w", message.ID, markErr)
+			}
+			d.observer().MessageFailed(message, err)
+		}
+
+		return nil
+	}
+
+	if err := d.repository.MarkMessageAsSent(ctx, message.ID, true); err != nil {
+		return fmt.Errorf("failed to mark message %s as sent: %w", message.ID, err)
+	}
+	d.observer().MessagePublished(message)
+
 	return nil
 }
+
+func (d *DefaultOutboxMessageDispatcher) observer() core.OutboxObserver {
+	if d.configs.Observer == nil {
+		return core.NopOutboxObserver{}
+	}
+
+	return d.configs.Observer
+}
+
+// sleep waits for d, or returns the context's error if it is done first.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
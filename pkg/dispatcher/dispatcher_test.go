@@ -5,7 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"go-transactional-outbox/pkg/core"
+	"go-transactional-outbox/pkg/publisher/publishertest"
+	"go-transactional-outbox/pkg/repository/memory"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -20,18 +25,23 @@ func (m *MockOutboxMessageRepository) SaveMessage(ctx context.Context, message c
 	return args.Error(0)
 }
 
-func (m *MockOutboxMessageRepository) FetchPendingMessages(ctx context.Context, limit int, processingLockTimeout uint32) ([]core.OutboxMessage, error) {
-	args := m.Called(ctx, limit)
+func (m *MockOutboxMessageRepository) FetchPendingMessages(ctx context.Context, limit uint32, processingLockTimeout uint32) ([]core.OutboxMessage, error) {
+	args := m.Called(ctx, limit, processingLockTimeout)
 	return args.Get(0).([]core.OutboxMessage), args.Error(1)
 }
 
-func (m *MockOutboxMessageRepository) MarkMessageAsSent(ctx context.Context, id string) error {
-	args := m.Called(ctx, id)
+func (m *MockOutboxMessageRepository) MarkMessageAsSent(ctx context.Context, id string, shouldIncrementAttempts bool) error {
+	args := m.Called(ctx, id, shouldIncrementAttempts)
 	return args.Error(0)
 }
 
-func (m *MockOutboxMessageRepository) MarkMessageAsFailed(ctx context.Context, id string, error string) error {
-	args := m.Called(ctx, id, error)
+func (m *MockOutboxMessageRepository) MarkMessageAsFailed(ctx context.Context, id string, shouldIncrementAttempts bool) error {
+	args := m.Called(ctx, id, shouldIncrementAttempts)
+	return args.Error(0)
+}
+
+func (m *MockOutboxMessageRepository) MarkMessageForRetry(ctx context.Context, id string, delay time.Duration, shouldIncrementAttempts bool) error {
+	args := m.Called(ctx, id, delay, shouldIncrementAttempts)
 	return args.Error(0)
 }
 
@@ -64,8 +74,8 @@ func TestDefaultOutboxMessageDispatcher_Success(t *testing.T) {
 	mockRepo.On("FetchPendingMessages", ctx, dispatcher.configs.FetchLimit, dispatcher.configs.ProcessingLockTimeout).Return(messages, nil)
 	mockPub.On("Publish", ctx, messages[0]).Return(nil)
 	mockPub.On("Publish", ctx, messages[1]).Return(nil)
-	mockRepo.On("MarkMessageAsSent", ctx, "1").Return(nil)
-	mockRepo.On("MarkMessageAsSent", ctx, "2").Return(nil)
+	mockRepo.On("MarkMessageAsSent", ctx, "1", true).Return(nil)
+	mockRepo.On("MarkMessageAsSent", ctx, "2", true).Return(nil)
 
 	err := dispatcher.Dispatch(ctx)
 	assert.NoError(t, err)
@@ -94,8 +104,8 @@ func TestDefaultOutboxMessageDispatcher_PartialFailure(t *testing.T) {
 	mockRepo.On("FetchPendingMessages", ctx, dispatcher.configs.FetchLimit, dispatcher.configs.ProcessingLockTimeout).Return(messages, nil)
 	mockPub.On("Publish", ctx, messages[0]).Return(nil)                             // First succeeds
 	mockPub.On("Publish", ctx, messages[1]).Return(errors.New("failed to publish")) // Second fails
-	mockRepo.On("MarkMessageAsSent", ctx, "1").Return(nil)
-	mockRepo.On("MarkMessageAsFailed", ctx, "2", "failed to publish").Return(nil)
+	mockRepo.On("MarkMessageAsSent", ctx, "1", true).Return(nil)
+	mockRepo.On("MarkMessageForRetry", ctx, "2", mock.AnythingOfType("time.Duration"), true).Return(nil)
 
 	err := dispatcher.Dispatch(ctx)
 	assert.NoError(t, err)
@@ -126,3 +136,426 @@ func TestDefaultOutboxMessageDispatcher_RepositoryFetchError(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 	mockPub.AssertExpectations(t)
 }
+
+func TestDefaultOutboxMessageDispatcher_SettleError(t *testing.T) {
+	mockRepo := new(MockOutboxMessageRepository)
+	mockPub := new(MockOutboxMessagePublisher)
+	observer := &recordingObserver{}
+
+	configs := DefaultDispatcherConfigs()
+	configs.Observer = observer
+
+	dispatcher := &DefaultOutboxMessageDispatcher{
+		repository: mockRepo,
+		publisher:  mockPub,
+		configs:    configs,
+	}
+
+	ctx := context.Background()
+
+	messages := []core.OutboxMessage{
+		{ID: "1", Payload: "Test Message 1", Status: core.MessageStatusPending},
+		{ID: "2", Payload: "Test Message 2", Status: core.MessageStatusPending},
+	}
+
+	mockRepo.On("FetchPendingMessages", ctx, dispatcher.configs.FetchLimit, dispatcher.configs.ProcessingLockTimeout).Return(messages, nil)
+	mockPub.On("Publish", ctx, messages[0]).Return(nil)
+	mockPub.On("Publish", ctx, messages[1]).Return(errors.New("failed to publish"))
+	mockRepo.On("MarkMessageAsSent", ctx, "1", true).Return(errors.New("connection reset"))
+	mockRepo.On("MarkMessageForRetry", ctx, "2", mock.AnythingOfType("time.Duration"), true).Return(errors.New("connection reset"))
+
+	err := dispatcher.Dispatch(ctx)
+	assert.EqualError(t, err, "failed to mark message 1 as sent: connection reset\nfailed to mark message 2 for retry: connection reset")
+	assert.Empty(t, observer.published, "Unsettled messages must not be observed")
+	assert.Empty(t, observer.retried, "Unsettled messages must not be observed")
+
+	mockRepo.AssertExpectations(t)
+	mockPub.AssertExpectations(t)
+}
+
+func TestDefaultOutboxMessageDispatcher_PermanentErrorFailsWithoutRetry(t *testing.T) {
+	mockRepo := new(MockOutboxMessageRepository)
+	mockPub := new(MockOutboxMessagePublisher)
+
+	dispatcher := &DefaultOutboxMessageDispatcher{
+		repository: mockRepo,
+		publisher:  mockPub,
+		configs:    DefaultDispatcherConfigs(),
+	}
+
+	ctx := context.Background()
+
+	messages := []core.OutboxMessage{
+		{ID: "1", Payload: "Test Message 1", Status: core.MessageStatusPending},
+	}
+
+	mockRepo.On("FetchPendingMessages", ctx, dispatcher.configs.FetchLimit, dispatcher.configs.ProcessingLockTimeout).Return(messages, nil)
+	mockPub.On("Publish", ctx, messages[0]).Return(core.NewPermanentError(errors.New("payload rejected")))
+	mockRepo.On("MarkMessageAsFailed", ctx, "1", true).Return(nil)
+
+	err := dispatcher.Dispatch(ctx)
+	assert.NoError(t, err)
+
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "MarkMessageForRetry", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockPub.AssertExpectations(t)
+}
+
+func TestDefaultOutboxMessageDispatcher_RetryAfterExtendsDelay(t *testing.T) {
+	mockRepo := new(MockOutboxMessageRepository)
+	mockPub := new(MockOutboxMessagePublisher)
+
+	dispatcher := &DefaultOutboxMessageDispatcher{
+		repository: mockRepo,
+		publisher:  mockPub,
+		configs:    DefaultDispatcherConfigs(),
+	}
+
+	ctx := context.Background()
+
+	messages := []core.OutboxMessage{
+		{ID: "1", Payload: "Test Message 1", Status: core.MessageStatusPending},
+	}
+
+	mockRepo.On("FetchPendingMessages", ctx, dispatcher.configs.FetchLimit, dispatcher.configs.ProcessingLockTimeout).Return(messages, nil)
+	mockPub.On("Publish", ctx, messages[0]).Return(core.NewRetryAfterError(errors.New("rate limited"), 2*time.Minute))
+	mockRepo.On("MarkMessageForRetry", ctx, "1", 2*time.Minute, true).Return(nil)
+
+	err := dispatcher.Dispatch(ctx)
+	assert.NoError(t, err)
+
+	mockRepo.AssertExpectations(t)
+	mockPub.AssertExpectations(t)
+}
+
+type MockOutboxMessageBatchPublisher struct {
+	MockOutboxMessagePublisher
+}
+
+func (m *MockOutboxMessageBatchPublisher) PublishBatch(ctx context.Context, messages []core.OutboxMessage) error {
+	args := m.Called(ctx, messages)
+	return args.Error(0)
+}
+
+func TestDefaultOutboxMessageDispatcher_BatchPartialFailureRetriesOnlyFailed(t *testing.T) {
+	mockRepo := new(MockOutboxMessageRepository)
+	mockPub := new(MockOutboxMessageBatchPublisher)
+
+	dispatcher := &DefaultOutboxMessageDispatcher{
+		repository: mockRepo,
+		publisher:  mockPub,
+		configs:    DefaultDispatcherConfigs(),
+	}
+
+	ctx := context.Background()
+
+	messages := []core.OutboxMessage{
+		{ID: "1", Payload: "Test Message 1", Status: core.MessageStatusPending},
+		{ID: "2", Payload: "Test Message 2", Status: core.MessageStatusPending},
+		{ID: "3", Payload: "Test Message 3", Status: core.MessageStatusPending, Attempts: 4},
+	}
+
+	mockRepo.On("FetchPendingMessages", ctx, dispatcher.configs.FetchLimit, dispatcher.configs.ProcessingLockTimeout).Return(messages, nil)
+	mockRepo.On("MarkMessageAsFailed", ctx, "3", false).Return(nil) // Out of retry attempts before publishing
+	mockPub.On("PublishBatch", ctx, messages[:2]).Return(&core.BatchPublishError{
+		Errors: map[string]error{"2": errors.New("throttled")},
+	})
+	mockRepo.On("MarkMessageAsSent", ctx, "1", true).Return(nil)
+	mockRepo.On("MarkMessageForRetry", ctx, "2", mock.AnythingOfType("time.Duration"), true).Return(nil)
+
+	err := dispatcher.Dispatch(ctx)
+	assert.NoError(t, err)
+
+	mockRepo.AssertExpectations(t)
+	mockPub.AssertExpectations(t)
+	mockPub.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+}
+
+func TestDefaultOutboxMessageDispatcher_BatchFailureRetriesAll(t *testing.T) {
+	mockRepo := new(MockOutboxMessageRepository)
+	mockPub := new(MockOutboxMessageBatchPublisher)
+
+	dispatcher := &DefaultOutboxMessageDispatcher{
+		repository: mockRepo,
+		publisher:  mockPub,
+		configs:    DefaultDispatcherConfigs(),
+	}
+
+	ctx := context.Background()
+
+	messages := []core.OutboxMessage{
+		{ID: "1", Payload: "Test Message 1", Status: core.MessageStatusPending},
+		{ID: "2", Payload: "Test Message 2", Status: core.MessageStatusPending},
+	}
+
+	mockRepo.On("FetchPendingMessages", ctx, dispatcher.configs.FetchLimit, dispatcher.configs.ProcessingLockTimeout).Return(messages, nil)
+	mockPub.On("PublishBatch", ctx, messages).Return(errors.New("connection reset"))
+	mockRepo.On("MarkMessageForRetry", ctx, "1", mock.AnythingOfType("time.Duration"), true).Return(nil)
+	mockRepo.On("MarkMessageForRetry", ctx, "2", mock.AnythingOfType("time.Duration"), true).Return(nil)
+
+	err := dispatcher.Dispatch(ctx)
+	assert.NoError(t, err)
+
+	mockRepo.AssertExpectations(t)
+	mockPub.AssertExpectations(t)
+}
+
+// fakeNotifier delivers the notifications sent on its channel.
+type fakeNotifier chan struct{}
+
+func (n fakeNotifier) Notifications() <-chan struct{} {
+	return n
+}
+
+// runDispatcher runs a dispatcher in the background until the test ends.
+func runDispatcher(t *testing.T, dispatcher *DefaultOutboxMessageDispatcher) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+
+	go func() { done <- dispatcher.Run(ctx) }()
+
+	t.Cleanup(func() {
+		cancel()
+		assert.ErrorIs(t, <-done, context.Canceled)
+	})
+}
+
+func saveMessages(t *testing.T, repo core.OutboxMessageRepository, ids ...string) {
+	for _, id := range ids {
+		err := repo.SaveMessage(context.Background(), core.OutboxMessage{ID: id, Payload: "Payload " + id, Status: core.MessageStatusPending})
+		assert.NoError(t, err)
+	}
+}
+
+func TestDefaultOutboxMessageDispatcher_Run_Polls(t *testing.T) {
+	repo := memory.NewMemoryRepository()
+	pub := publishertest.NewPublisher()
+
+	configs := DefaultDispatcherConfigs()
+	configs.PollInterval = 20 * time.Millisecond
+
+	runDispatcher(t, NewDefaultOutboxMessageDispatcher(repo, pub, configs))
+
+	saveMessages(t, repo, "1")
+
+	assert.Eventually(t, func() bool { return len(pub.Published()) == 1 }, time.Second, 5*time.Millisecond)
+}
+
+func TestDefaultOutboxMessageDispatcher_Run_DispatchesOnNotification(t *testing.T) {
+	repo := memory.NewMemoryRepository()
+	pub := publishertest.NewPublisher()
+	notifier := make(fakeNotifier)
+
+	configs := DefaultDispatcherConfigs()
+	configs.PollInterval = time.Hour
+	configs.Notifier = notifier
+
+	runDispatcher(t, NewDefaultOutboxMessageDispatcher(repo, pub, configs))
+
+	saveMessages(t, repo, "1")
+	notifier <- struct{}{}
+
+	assert.Eventually(t, func() bool { return len(pub.Published()) == 1 }, time.Second, 5*time.Millisecond)
+}
+
+func TestDefaultOutboxMessageDispatcher_Run_FallsBackToPollingWhenNotifierStops(t *testing.T) {
+	repo := memory.NewMemoryRepository()
+	pub := publishertest.NewPublisher()
+	notifier := make(fakeNotifier)
+	close(notifier)
+
+	configs := DefaultDispatcherConfigs()
+	configs.PollInterval = 20 * time.Millisecond
+	configs.Notifier = notifier
+
+	runDispatcher(t, NewDefaultOutboxMessageDispatcher(repo, pub, configs))
+
+	saveMessages(t, repo, "1")
+
+	assert.Eventually(t, func() bool { return len(pub.Published()) == 1 }, time.Second, 5*time.Millisecond)
+}
+
+func TestDefaultOutboxMessageDispatcher_Run_DrainsFullBatches(t *testing.T) {
+	repo := memory.NewMemoryRepository()
+	pub := publishertest.NewPublisher()
+
+	configs := DefaultDispatcherConfigs()
+	configs.FetchLimit = 2
+	configs.PollInterval = time.Hour
+
+	saveMessages(t, repo, "1", "2", "3", "4", "5")
+
+	runDispatcher(t, NewDefaultOutboxMessageDispatcher(repo, pub, configs))
+
+	assert.Eventually(t, func() bool { return len(pub.Published()) == 5 }, time.Second, 5*time.Millisecond)
+}
+
+// fakeLeaderElector grants leadership while canLead is set, and revokes it with loseLeadership.
+type fakeLeaderElector struct {
+	mu      sync.Mutex
+	canLead bool
+	cancel  context.CancelFunc
+}
+
+func (e *fakeLeaderElector) AcquireLeadership(ctx context.Context) (context.Context, func(), error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !e.canLead {
+		return nil, nil, core.ErrNotLeader
+	}
+
+	leaderCtx, cancel := context.WithCancel(ctx)
+	e.cancel = cancel
+
+	return leaderCtx, cancel, nil
+}
+
+func (e *fakeLeaderElector) setCanLead(canLead bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.canLead = canLead
+}
+
+func (e *fakeLeaderElector) loseLeadership() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.canLead = false
+	if e.cancel != nil {
+		e.cancel()
+	}
+}
+
+func TestDefaultOutboxMessageDispatcher_Run_DispatchesOnlyAsLeader(t *testing.T) {
+	repo := memory.NewMemoryRepository()
+	pub := publishertest.NewPublisher()
+	elector := &fakeLeaderElector{}
+
+	configs := DefaultDispatcherConfigs()
+	configs.PollInterval = 10 * time.Millisecond
+	configs.LeaderElector = elector
+
+	runDispatcher(t, NewDefaultOutboxMessageDispatcher(repo, pub, configs))
+
+	saveMessages(t, repo, "1")
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, pub.Published(), "Standby dispatchers must not dispatch")
+
+	elector.setCanLead(true)
+	assert.Eventually(t, func() bool { return len(pub.Published()) == 1 }, time.Second, 5*time.Millisecond)
+
+	elector.loseLeadership()
+	time.Sleep(20 * time.Millisecond)
+	saveMessages(t, repo, "2")
+
+	time.Sleep(50 * time.Millisecond)
+	pub.AssertPublishedInOrder(t, "1")
+
+	// A standby takes over once leadership is available again.
+	elector.setCanLead(true)
+	assert.Eventually(t, func() bool { return len(pub.Published()) == 2 }, time.Second, 5*time.Millisecond)
+}
+
+// fakePartitionLeaser records whether it runs, and takes a moment to give its partitions up.
+type fakePartitionLeaser struct {
+	running  atomic.Bool
+	released atomic.Bool
+}
+
+func (l *fakePartitionLeaser) Run(ctx context.Context) error {
+	l.running.Store(true)
+	<-ctx.Done()
+
+	time.Sleep(20 * time.Millisecond)
+	l.released.Store(true)
+
+	return ctx.Err()
+}
+
+func TestDefaultOutboxMessageDispatcher_Run_RunsPartitionLeaser(t *testing.T) {
+	leaser := &fakePartitionLeaser{}
+
+	configs := DefaultDispatcherConfigs()
+	configs.PollInterval = 10 * time.Millisecond
+	configs.PartitionLeaser = leaser
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+
+	go func() {
+		done <- NewDefaultOutboxMessageDispatcher(memory.NewMemoryRepository(), publishertest.NewPublisher(), configs).Run(ctx)
+	}()
+
+	assert.Eventually(t, leaser.running.Load, time.Second, 5*time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+	assert.True(t, leaser.released.Load(), "Run must wait for the leaser to give its partitions up")
+}
+
+// recordingObserver records the events it observes.
+type recordingObserver struct {
+	core.NopOutboxObserver
+	mu                sync.Mutex
+	published         []string
+	retried           []string
+	failed            []string
+	cleanedUp         map[core.MessageStatus]int
+	partitionsDropped int
+}
+
+func (o *recordingObserver) MessagePublished(message core.OutboxMessage) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.published = append(o.published, message.ID)
+}
+
+func (o *recordingObserver) MessageRetried(message core.OutboxMessage, delay time.Duration, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.retried = append(o.retried, message.ID)
+}
+
+func (o *recordingObserver) MessageFailed(message core.OutboxMessage, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.failed = append(o.failed, message.ID)
+}
+
+func (o *recordingObserver) MessagesCleanedUp(status core.MessageStatus, count int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.cleanedUp == nil {
+		o.cleanedUp = make(map[core.MessageStatus]int)
+	}
+	o.cleanedUp[status] += count
+}
+
+func (o *recordingObserver) PartitionsDropped(count int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.partitionsDropped += count
+}
+
+func TestDefaultOutboxMessageDispatcher_Observer(t *testing.T) {
+	repo := memory.NewMemoryRepository()
+	pub := publishertest.NewPublisher()
+	pub.FailMessage("2", errors.New("broker unavailable"))
+	pub.FailMessage("3", core.NewPermanentError(errors.New("rejected")))
+	observer := &recordingObserver{}
+
+	configs := DefaultDispatcherConfigs()
+	configs.Observer = observer
+
+	saveMessages(t, repo, "1", "2", "3")
+
+	err := NewDefaultOutboxMessageDispatcher(repo, pub, configs).Dispatch(context.Background())
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"1"}, observer.published)
+	assert.Equal(t, []string{"2"}, observer.retried)
+	assert.Equal(t, []string{"3"}, observer.failed)
+}
@@ -0,0 +1,118 @@
+package dispatcher
+
+import (
+	"context"
+	"fmt"
+	"go-transactional-outbox/pkg/core"
+	"time"
+)
+
+// Janitor removes sent and failed messages once they are older than their retention period, so
+// the outbox does not grow without bound. It runs alongside the dispatcher.
+//
+// Messages are removed in batches with a pause in between, so a large backlog does not hold
+// locks or saturate the database. When the outbox is partitioned by creation time and messages
+// are not archived, partitions older than both retention periods are dropped first, which leaves
+// only the rows of recent partitions to be deleted one batch at a time.
+type Janitor struct {
+	repository core.OutboxRetentionRepository
+	configs    JanitorConfigs
+	now        func() time.Time
+}
+
+func NewJanitor(repository core.OutboxRetentionRepository, configs JanitorConfigs) *Janitor {
+	return &Janitor{
+		repository: repository,
+		configs:    configs,
+		now:        time.Now,
+	}
+}
+
+// Run cleans up every Interval until ctx is done, then returns the context's error. Failed
+// cleanups do not stop the loop; the next one resumes where they stopped.
+func (j *Janitor) Run(ctx context.Context) error {
+	interval := j.configs.Interval
+	if interval <= 0 {
+		interval = DefaultJanitorConfigs().Interval
+	}
+
+	for {
+		_ = j.Clean(ctx)
+
+		if err := sleep(ctx, interval); err != nil {
+			return err
+		}
+	}
+}
+
+// Clean removes every expired message.
+func (j *Janitor) Clean(ctx context.Context) error {
+	now := j.now()
+
+	if j.configs.Partitions != nil && !j.configs.Archive && j.configs.SentRetention > 0 && j.configs.FailedRetention > 0 {
+		retention := max(j.configs.SentRetention, j.configs.FailedRetention)
+
+		dropped, err := j.configs.Partitions.DropPartitions(ctx, now.Add(-retention))
+		if err != nil {
+			return fmt.Errorf("failed to drop partitions: %w", err)
+		}
+
+		if dropped > 0 {
+			j.observer().PartitionsDropped(dropped)
+		}
+	}
+
+	if j.configs.SentRetention > 0 {
+		if err := j.removeMessages(ctx, core.MessageStatusSent, now.Add(-j.configs.SentRetention)); err != nil {
+			return err
+		}
+	}
+
+	if j.configs.FailedRetention > 0 {
+		if err := j.removeMessages(ctx, core.MessageStatusFailed, now.Add(-j.configs.FailedRetention)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// removeMessages removes messages in batches until a batch is not full.
+func (j *Janitor) removeMessages(ctx context.Context, status core.MessageStatus, createdBefore time.Time) error {
+	batchSize := j.configs.BatchSize
+	if batchSize == 0 {
+		batchSize = DefaultJanitorConfigs().BatchSize
+	}
+
+	remove := j.repository.DeleteMessages
+	if j.configs.Archive {
+		remove = j.repository.ArchiveMessages
+	}
+
+	for {
+		removed, err := remove(ctx, status, createdBefore, batchSize)
+		if err != nil {
+			return fmt.Errorf("failed to remove %s messages: %w", status, err)
+		}
+
+		if removed > 0 {
+			j.observer().MessagesCleanedUp(status, removed)
+		}
+
+		if uint32(removed) < batchSize {
+			return nil
+		}
+
+		if err := sleep(ctx, j.configs.BatchPause); err != nil {
+			return err
+		}
+	}
+}
+
+func (j *Janitor) observer() core.OutboxObserver {
+	if j.configs.Observer == nil {
+		return core.NopOutboxObserver{}
+	}
+
+	return j.configs.Observer
+}
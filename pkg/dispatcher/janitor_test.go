@@ -0,0 +1,145 @@
+package dispatcher
+
+import (
+	"context"
+	"errors"
+	"go-transactional-outbox/pkg/core"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type removal struct {
+	status        core.MessageStatus
+	createdBefore time.Time
+	archived      bool
+}
+
+// fakeRetentionRepository pretends to hold a number of expired messages per status.
+type fakeRetentionRepository struct {
+	expired  map[core.MessageStatus]int
+	removals []removal
+	err      error
+}
+
+func (r *fakeRetentionRepository) DeleteMessages(ctx context.Context, status core.MessageStatus, createdBefore time.Time, limit uint32) (int, error) {
+	return r.remove(status, createdBefore, limit, false)
+}
+
+func (r *fakeRetentionRepository) ArchiveMessages(ctx context.Context, status core.MessageStatus, createdBefore time.Time, limit uint32) (int, error) {
+	return r.remove(status, createdBefore, limit, true)
+}
+
+func (r *fakeRetentionRepository) remove(status core.MessageStatus, createdBefore time.Time, limit uint32, archived bool) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	r.removals = append(r.removals, removal{status: status, createdBefore: createdBefore, archived: archived})
+
+	removed := min(r.expired[status], int(limit))
+	r.expired[status] -= removed
+
+	return removed, nil
+}
+
+// fakePartitionDropper records when partitions were dropped.
+type fakePartitionDropper struct {
+	droppedBefore []time.Time
+}
+
+func (r *fakePartitionDropper) DropPartitions(ctx context.Context, createdBefore time.Time) (int, error) {
+	r.droppedBefore = append(r.droppedBefore, createdBefore)
+	return 2, nil
+}
+
+var janitorNow = time.Date(2025, 1, 31, 12, 0, 0, 0, time.UTC)
+
+func newTestJanitor(repository core.OutboxRetentionRepository, configs JanitorConfigs) *Janitor {
+	janitor := NewJanitor(repository, configs)
+	janitor.now = func() time.Time { return janitorNow }
+
+	return janitor
+}
+
+func TestJanitor_Clean_RemovesInBatches(t *testing.T) {
+	repo := &fakeRetentionRepository{expired: map[core.MessageStatus]int{core.MessageStatusSent: 25, core.MessageStatusFailed: 3}}
+	observer := &recordingObserver{}
+
+	configs := DefaultJanitorConfigs()
+	configs.BatchSize = 10
+	configs.BatchPause = time.Millisecond
+	configs.Observer = observer
+
+	require.NoError(t, newTestJanitor(repo, configs).Clean(context.Background()))
+
+	sentBefore := janitorNow.Add(-configs.SentRetention)
+	failedBefore := janitorNow.Add(-configs.FailedRetention)
+
+	assert.Equal(t, []removal{
+		{status: core.MessageStatusSent, createdBefore: sentBefore},
+		{status: core.MessageStatusSent, createdBefore: sentBefore},
+		{status: core.MessageStatusSent, createdBefore: sentBefore},
+		{status: core.MessageStatusFailed, createdBefore: failedBefore},
+	}, repo.removals)
+	assert.Equal(t, map[core.MessageStatus]int{core.MessageStatusSent: 25, core.MessageStatusFailed: 3}, observer.cleanedUp)
+}
+
+func TestJanitor_Clean_Archives(t *testing.T) {
+	repo := &fakeRetentionRepository{expired: map[core.MessageStatus]int{core.MessageStatusSent: 1}}
+
+	configs := DefaultJanitorConfigs()
+	configs.Archive = true
+	configs.FailedRetention = 0
+
+	require.NoError(t, newTestJanitor(repo, configs).Clean(context.Background()))
+
+	require.Len(t, repo.removals, 1, "Failed messages without retention must be kept")
+	assert.True(t, repo.removals[0].archived)
+}
+
+func TestJanitor_Clean_DropsPartitions(t *testing.T) {
+	repo := &fakeRetentionRepository{expired: map[core.MessageStatus]int{}}
+	partitions := &fakePartitionDropper{}
+	observer := &recordingObserver{}
+
+	configs := DefaultJanitorConfigs()
+	configs.Observer = observer
+	configs.Partitions = partitions
+
+	require.NoError(t, newTestJanitor(repo, configs).Clean(context.Background()))
+
+	assert.Equal(t, []time.Time{janitorNow.Add(-configs.FailedRetention)}, partitions.droppedBefore, "Partitions are dropped once both retention periods passed")
+	assert.Equal(t, 2, observer.partitionsDropped)
+	assert.Len(t, repo.removals, 2, "Recent partitions are still cleaned up row by row")
+
+	configs.Archive = true
+	partitions.droppedBefore = nil
+
+	require.NoError(t, newTestJanitor(repo, configs).Clean(context.Background()))
+	assert.Empty(t, partitions.droppedBefore, "Archived messages must not be dropped")
+}
+
+func TestJanitor_Clean_Error(t *testing.T) {
+	repoErr := errors.New("connection reset")
+	repo := &fakeRetentionRepository{err: repoErr}
+
+	err := newTestJanitor(repo, DefaultJanitorConfigs()).Clean(context.Background())
+
+	assert.ErrorIs(t, err, repoErr)
+}
+
+func TestJanitor_Run(t *testing.T) {
+	repo := &fakeRetentionRepository{expired: map[core.MessageStatus]int{core.MessageStatusSent: 1}}
+
+	configs := DefaultJanitorConfigs()
+	configs.Interval = 10 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 55*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, newTestJanitor(repo, configs).Run(ctx), context.DeadlineExceeded)
+	assert.Greater(t, len(repo.removals), 4, "Run must clean up every interval")
+}
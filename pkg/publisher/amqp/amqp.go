@@ -0,0 +1,232 @@
+package amqp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-transactional-outbox/pkg/core"
+	"sync"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Message headers that control how a message is published. They are not forwarded as AMQP headers.
+const (
+	HeaderExchange   = "amqp-exchange"    // Overrides the publisher's default exchange.
+	HeaderRoutingKey = "amqp-routing-key" // Overrides the publisher's default routing key.
+)
+
+var (
+	ErrMessageNacked   = errors.New("message was nacked by the broker")
+	ErrMessageReturned = errors.New("message was returned as unroutable")
+	ErrChannelClosed   = errors.New("channel was closed before the message was confirmed")
+)
+
+// AMQPChannel represents the methods of *amqp.Channel used by the publisher.
+type AMQPChannel interface {
+	Confirm(noWait bool) error
+	NotifyPublish(confirm chan amqp.Confirmation) chan amqp.Confirmation
+	NotifyReturn(c chan amqp.Return) chan amqp.Return
+	PublishWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+	Close() error
+}
+
+// ChannelFactory opens a new channel. It is called on the first publish and whenever the
+// previous channel was lost, which is how the publisher recovers from connection failures.
+type ChannelFactory func() (AMQPChannel, error)
+
+type AMQPPublisher struct {
+	openChannel ChannelFactory
+	exchange    string
+	routingKey  string
+
+	mu       sync.Mutex
+	channel  AMQPChannel
+	confirms chan amqp.Confirmation
+	returns  chan amqp.Return
+}
+
+func NewAMQPOutboxMessagePublisher(url string, exchange string, routingKey string) (*AMQPPublisher, error) {
+	publisher := &AMQPPublisher{
+		openChannel: DialChannelFactory(url),
+		exchange:    exchange,
+		routingKey:  routingKey,
+	}
+
+	// Connect eagerly so that configuration errors surface at startup rather than on the first dispatch.
+	publisher.mu.Lock()
+	defer publisher.mu.Unlock()
+
+	if err := publisher.ensureChannel(); err != nil {
+		return nil, err
+	}
+
+	return publisher, nil
+}
+
+// DialChannelFactory returns a ChannelFactory that opens a dedicated connection for every channel.
+func DialChannelFactory(url string) ChannelFactory {
+	return func() (AMQPChannel, error) {
+		connection, err := amqp.Dial(url)
+		if err != nil {
+			return nil, err
+		}
+
+		channel, err := connection.Channel()
+		if err != nil {
+			_ = connection.Close()
+			return nil, err
+		}
+
+		return &connectionChannel{Channel: channel, connection: connection}, nil
+	}
+}
+
+// Publish sends the message as mandatory and waits for the publisher confirm. A nil error
+// means the broker acknowledged the message and routed it to at least one queue.
+//
+// Messages are published one at a time, so every confirmation belongs to the message just sent.
+func (p *AMQPPublisher) Publish(ctx context.Context, message core.OutboxMessage) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.ensureChannel(); err != nil {
+		return err
+	}
+
+	exchange, routingKey, publishing := p.newPublishing(message)
+
+	// amqp091 ignores the context of PublishWithContext, so a done context must be caught here.
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("failed to publish message to AMQP: %w", err)
+	}
+
+	if err := p.channel.PublishWithContext(ctx, exchange, routingKey, true, false, publishing); err != nil {
+		p.discardChannel()
+		return fmt.Errorf("failed to publish message to AMQP: %w", err)
+	}
+
+	returns := p.returns
+	returned := false
+
+	for {
+		select {
+		case <-ctx.Done():
+			// The confirmation may still arrive and would be taken for the next message's.
+			p.discardChannel()
+			return fmt.Errorf("failed to publish message to AMQP: %w", ctx.Err())
+
+		case _, ok := <-returns:
+			if !ok {
+				returns = nil // The channel is closing; the confirmations channel reports it.
+				continue
+			}
+			returned = true
+
+		case confirmation, ok := <-p.confirms:
+			if !ok {
+				p.discardChannel()
+				return fmt.Errorf("failed to publish message to AMQP: %w", ErrChannelClosed)
+			}
+
+			// The broker sends basic.return before basic.ack, but both may be ready at once.
+			select {
+			case _, ok := <-returns:
+				returned = returned || ok
+			default:
+			}
+
+			if !confirmation.Ack {
+				return fmt.Errorf("failed to publish message to AMQP: %w", ErrMessageNacked)
+			}
+
+			if returned {
+				return fmt.Errorf("failed to publish message to AMQP: %w", ErrMessageReturned)
+			}
+
+			return nil
+		}
+	}
+}
+
+// Close closes the current channel, if any. The publisher reopens one if it is used again.
+func (p *AMQPPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.channel == nil {
+		return nil
+	}
+
+	err := p.channel.Close()
+	p.channel = nil
+
+	return err
+}
+
+func (p *AMQPPublisher) ensureChannel() error {
+	if p.channel != nil {
+		return nil
+	}
+
+	channel, err := p.openChannel()
+	if err != nil {
+		return fmt.Errorf("failed to open AMQP channel: %w", err)
+	}
+
+	if err := channel.Confirm(false); err != nil {
+		_ = channel.Close()
+		return fmt.Errorf("failed to enable publisher confirms: %w", err)
+	}
+
+	p.channel = channel
+	p.confirms = channel.NotifyPublish(make(chan amqp.Confirmation, 1))
+	p.returns = channel.NotifyReturn(make(chan amqp.Return, 1))
+
+	return nil
+}
+
+// discardChannel drops a channel whose state is no longer known, so the next publish opens a new one.
+func (p *AMQPPublisher) discardChannel() {
+	if p.channel == nil {
+		return
+	}
+
+	_ = p.channel.Close()
+	p.channel = nil
+}
+
+func (p *AMQPPublisher) newPublishing(message core.OutboxMessage) (string, string, amqp.Publishing) {
+	exchange := p.exchange
+	routingKey := p.routingKey
+	headers := amqp.Table{}
+
+	for name, value := range message.Headers {
+		switch name {
+		case HeaderExchange:
+			exchange = value
+		case HeaderRoutingKey:
+			routingKey = value
+		default:
+			headers[name] = value
+		}
+	}
+
+	return exchange, routingKey, amqp.Publishing{
+		MessageId:    message.ID,
+		Headers:      headers,
+		DeliveryMode: amqp.Persistent,
+		Body:         []byte(message.Payload),
+	}
+}
+
+// connectionChannel closes the connection together with the channel it was opened for.
+type connectionChannel struct {
+	*amqp.Channel
+	connection *amqp.Connection
+}
+
+func (c *connectionChannel) Close() error {
+	_ = c.Channel.Close()
+	return c.connection.Close()
+}
@@ -0,0 +1,235 @@
+package amqp
+
+import (
+	"context"
+	"errors"
+	"go-transactional-outbox/pkg/core"
+	"go-transactional-outbox/pkg/publisher/publishertest"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type publishedMessage struct {
+	exchange   string
+	routingKey string
+	mandatory  bool
+	publishing amqp.Publishing
+}
+
+// FakeAMQPChannel behaves like a broker channel in confirm mode. The outcome of every
+// publish is decided by the respond function, which can ack, nack, return or close.
+type FakeAMQPChannel struct {
+	confirms  chan amqp.Confirmation
+	returns   chan amqp.Return
+	published []publishedMessage
+	closed    bool
+	respond   func(ch *FakeAMQPChannel, msg amqp.Publishing)
+	publishFn func() error
+}
+
+func (f *FakeAMQPChannel) Confirm(noWait bool) error { return nil }
+
+func (f *FakeAMQPChannel) NotifyPublish(confirm chan amqp.Confirmation) chan amqp.Confirmation {
+	f.confirms = confirm
+	return confirm
+}
+
+func (f *FakeAMQPChannel) NotifyReturn(c chan amqp.Return) chan amqp.Return {
+	f.returns = c
+	return c
+}
+
+func (f *FakeAMQPChannel) PublishWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	if f.publishFn != nil {
+		if err := f.publishFn(); err != nil {
+			return err
+		}
+	}
+
+	f.published = append(f.published, publishedMessage{exchange, key, mandatory, msg})
+	if f.respond != nil {
+		f.respond(f, msg)
+	}
+
+	return nil
+}
+
+func (f *FakeAMQPChannel) Close() error {
+	if !f.closed {
+		f.closed = true
+		close(f.confirms)
+		close(f.returns)
+	}
+	return nil
+}
+
+func ack(ch *FakeAMQPChannel, msg amqp.Publishing) {
+	ch.confirms <- amqp.Confirmation{DeliveryTag: uint64(len(ch.published)), Ack: true}
+}
+
+func newTestPublisher(channels ...*FakeAMQPChannel) (*AMQPPublisher, *int) {
+	opened := 0
+
+	return &AMQPPublisher{
+		openChannel: func() (AMQPChannel, error) {
+			if opened >= len(channels) {
+				return nil, errors.New("connection refused")
+			}
+			opened++
+			return channels[opened-1], nil
+		},
+		exchange:   "events",
+		routingKey: "default",
+	}, &opened
+}
+
+func TestAMQPPublisher_Publish_Success(t *testing.T) {
+	channel := &FakeAMQPChannel{respond: ack}
+	publisher, _ := newTestPublisher(channel)
+
+	testMessage := core.OutboxMessage{
+		ID:      "123",
+		Payload: "Test Payload",
+		Headers: map[string]string{HeaderRoutingKey: "orders.created", "event-type": "order.created"},
+		Status:  core.MessageStatusPending,
+	}
+
+	err := publisher.Publish(context.Background(), testMessage)
+	require.NoError(t, err)
+
+	require.Len(t, channel.published, 1)
+	published := channel.published[0]
+	assert.Equal(t, "events", published.exchange)
+	assert.Equal(t, "orders.created", published.routingKey)
+	assert.True(t, published.mandatory)
+	assert.Equal(t, "123", published.publishing.MessageId)
+	assert.Equal(t, amqp.Persistent, published.publishing.DeliveryMode)
+	assert.Equal(t, amqp.Table{"event-type": "order.created"}, published.publishing.Headers)
+	assert.Equal(t, "Test Payload", string(published.publishing.Body))
+}
+
+func TestAMQPPublisher_Publish_Nacked(t *testing.T) {
+	channel := &FakeAMQPChannel{respond: func(ch *FakeAMQPChannel, msg amqp.Publishing) {
+		ch.confirms <- amqp.Confirmation{DeliveryTag: 1, Ack: false}
+	}}
+	publisher, _ := newTestPublisher(channel)
+
+	err := publisher.Publish(context.Background(), core.OutboxMessage{ID: "123", Payload: "Test Payload"})
+
+	assert.ErrorIs(t, err, ErrMessageNacked)
+	assert.False(t, channel.closed, "A nack must not discard a healthy channel")
+}
+
+func TestAMQPPublisher_Publish_ReturnedAsUnroutable(t *testing.T) {
+	channel := &FakeAMQPChannel{respond: func(ch *FakeAMQPChannel, msg amqp.Publishing) {
+		ch.returns <- amqp.Return{ReplyCode: 312, ReplyText: "NO_ROUTE", MessageId: msg.MessageId}
+		ch.confirms <- amqp.Confirmation{DeliveryTag: 1, Ack: true}
+	}}
+	publisher, _ := newTestPublisher(channel)
+
+	err := publisher.Publish(context.Background(), core.OutboxMessage{ID: "123", Payload: "Test Payload"})
+
+	assert.EqualError(t, err, "failed to publish message to AMQP: message was returned as unroutable")
+	assert.ErrorIs(t, err, ErrMessageReturned)
+}
+
+func TestAMQPPublisher_Publish_RecoversClosedChannel(t *testing.T) {
+	broken := &FakeAMQPChannel{respond: func(ch *FakeAMQPChannel, msg amqp.Publishing) {
+		_ = ch.Close()
+	}}
+	healthy := &FakeAMQPChannel{respond: ack}
+	publisher, opened := newTestPublisher(broken, healthy)
+
+	err := publisher.Publish(context.Background(), core.OutboxMessage{ID: "1", Payload: "first"})
+	assert.ErrorIs(t, err, ErrChannelClosed)
+
+	err = publisher.Publish(context.Background(), core.OutboxMessage{ID: "2", Payload: "second"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, *opened)
+	require.Len(t, healthy.published, 1)
+	assert.Equal(t, "2", healthy.published[0].publishing.MessageId)
+}
+
+func TestAMQPPublisher_Publish_PublishErrorReopensChannel(t *testing.T) {
+	broken := &FakeAMQPChannel{publishFn: func() error { return amqp.ErrClosed }}
+	healthy := &FakeAMQPChannel{respond: ack}
+	publisher, opened := newTestPublisher(broken, healthy)
+
+	err := publisher.Publish(context.Background(), core.OutboxMessage{ID: "1", Payload: "first"})
+	assert.ErrorIs(t, err, amqp.ErrClosed)
+	assert.True(t, broken.closed)
+
+	err = publisher.Publish(context.Background(), core.OutboxMessage{ID: "1", Payload: "first"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, *opened)
+}
+
+func TestAMQPPublisher_Publish_ContextCanceledWhileWaitingForConfirm(t *testing.T) {
+	channel := &FakeAMQPChannel{}
+	publisher, _ := newTestPublisher(channel)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := publisher.Publish(ctx, core.OutboxMessage{ID: "123", Payload: "Test Payload"})
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.True(t, channel.closed, "A channel with an outstanding confirm must not be reused")
+}
+
+func TestAMQPPublisher_Publish_ConnectionFailure(t *testing.T) {
+	publisher, _ := newTestPublisher()
+
+	err := publisher.Publish(context.Background(), core.OutboxMessage{ID: "123", Payload: "Test Payload"})
+
+	assert.EqualError(t, err, "failed to open AMQP channel: connection refused")
+}
+
+func TestConformance(t *testing.T) {
+	publishertest.Run(t, func(t *testing.T) publishertest.Harness {
+		var channels []*FakeAMQPChannel
+		var failNext error
+
+		publisher := &AMQPPublisher{
+			openChannel: func() (AMQPChannel, error) {
+				channel := &FakeAMQPChannel{respond: ack}
+				channel.publishFn = func() error {
+					err := failNext
+					failNext = nil
+					return err
+				}
+
+				channels = append(channels, channel)
+				return channel, nil
+			},
+			exchange:   "events",
+			routingKey: "default",
+		}
+
+		return publishertest.Harness{
+			Publisher: publisher,
+			// The publisher reopens its channel after a failure, so messages may span several channels.
+			Received: func() []publishertest.Received {
+				var received []publishertest.Received
+				for _, channel := range channels {
+					for _, published := range channel.published {
+						headers := make(map[string]string, len(published.publishing.Headers))
+						for name, value := range published.publishing.Headers {
+							headers[name], _ = value.(string)
+						}
+						received = append(received, publishertest.Received{Payload: string(published.publishing.Body), Headers: headers})
+					}
+				}
+				return received
+			},
+			FailNext: func(err error) {
+				failNext = err
+			},
+		}
+	})
+}
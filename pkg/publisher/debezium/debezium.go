@@ -0,0 +1,73 @@
+package debezium
+
+import (
+	"context"
+	"fmt"
+	"go-transactional-outbox/pkg/core"
+)
+
+type EventRouterConfigs struct {
+	TopicHeader string // Header the wrapped publisher routes by, such as kafka.HeaderTopic. Empty keeps the publisher's default destination.
+	TopicPrefix string // Prefix of the topic named after the aggregate type, as route.topic.replacement in Debezium.
+	IDHeader    string // Header carrying the message ID.
+	TypeHeader  string // Header carrying the event type, as a "type:header:<name>" additional field placement in Debezium. Empty omits it.
+}
+
+// DefaultEventRouterConfigs matches the defaults of Debezium's outbox event router.
+func DefaultEventRouterConfigs(topicHeader string) EventRouterConfigs {
+	return EventRouterConfigs{
+		TopicHeader: topicHeader,
+		TopicPrefix: "outbox.event.",
+		IDHeader:    "id",
+	}
+}
+
+// EventRouterPublisher reshapes messages the way Debezium's outbox event router does before
+// handing them to another publisher: the topic is named after the aggregate type, the key is the
+// aggregate ID (the ordering key), the value is the payload, and the only headers are the message
+// ID and, optionally, the event type. Consumers therefore see the same messages whether the
+// outbox is relayed by a dispatcher or by Debezium.
+type EventRouterPublisher struct {
+	publisher core.OutboxMessagePublisher
+	configs   EventRouterConfigs
+}
+
+func NewEventRouterPublisher(publisher core.OutboxMessagePublisher, configs EventRouterConfigs) *EventRouterPublisher {
+	return &EventRouterPublisher{
+		publisher: publisher,
+		configs:   configs,
+	}
+}
+
+func (p *EventRouterPublisher) Publish(ctx context.Context, message core.OutboxMessage) error {
+	routed, err := p.route(message)
+	if err != nil {
+		return err
+	}
+
+	return p.publisher.Publish(ctx, routed)
+}
+
+func (p *EventRouterPublisher) route(message core.OutboxMessage) (core.OutboxMessage, error) {
+	aggregateType := message.Headers[core.HeaderAggregateType]
+	if aggregateType == "" {
+		return core.OutboxMessage{}, core.NewPermanentError(fmt.Errorf("message %s has no %s header to route by", message.ID, core.HeaderAggregateType))
+	}
+
+	headers := map[string]string{
+		p.configs.IDHeader: message.ID,
+	}
+
+	if eventType := message.Headers[core.HeaderEventType]; p.configs.TypeHeader != "" && eventType != "" {
+		headers[p.configs.TypeHeader] = eventType
+	}
+
+	if p.configs.TopicHeader != "" {
+		headers[p.configs.TopicHeader] = p.configs.TopicPrefix + aggregateType
+	}
+
+	routed := message
+	routed.Headers = headers
+
+	return routed, nil
+}
@@ -0,0 +1,114 @@
+package debezium
+
+import (
+	"context"
+	"errors"
+	"go-transactional-outbox/pkg/core"
+	"go-transactional-outbox/pkg/publisher/kafka"
+	"go-transactional-outbox/pkg/publisher/publishertest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventRouterPublisher_Publish(t *testing.T) {
+	inner := publishertest.NewPublisher()
+	configs := DefaultEventRouterConfigs(kafka.HeaderTopic)
+	configs.TypeHeader = "eventType"
+	publisher := NewEventRouterPublisher(inner, configs)
+
+	err := publisher.Publish(context.Background(), core.OutboxMessage{
+		ID:          "msg-1",
+		Payload:     `{"total":42}`,
+		OrderingKey: "order-7",
+		Headers: map[string]string{
+			core.HeaderAggregateType: "order",
+			core.HeaderEventType:     "OrderCreated",
+			"trace-id":               "abc",
+		},
+	})
+	require.NoError(t, err)
+
+	published := inner.Published()
+	require.Len(t, published, 1)
+	assert.Equal(t, `{"total":42}`, published[0].Payload)
+	assert.Equal(t, "order-7", published[0].OrderingKey, "The aggregate ID is the record key")
+	assert.Equal(t, map[string]string{
+		"id":              "msg-1",
+		"eventType":       "OrderCreated",
+		kafka.HeaderTopic: "outbox.event.order",
+	}, published[0].Headers)
+}
+
+func TestEventRouterPublisher_Defaults(t *testing.T) {
+	inner := publishertest.NewPublisher()
+	publisher := NewEventRouterPublisher(inner, DefaultEventRouterConfigs(""))
+
+	err := publisher.Publish(context.Background(), core.OutboxMessage{
+		ID:      "msg-1",
+		Headers: map[string]string{core.HeaderAggregateType: "order", core.HeaderEventType: "OrderCreated"},
+	})
+	require.NoError(t, err)
+
+	inner.AssertPublishedHeaders(t, "msg-1", map[string]string{"id": "msg-1"})
+}
+
+func TestEventRouterPublisher_MissingAggregateType(t *testing.T) {
+	inner := publishertest.NewPublisher()
+	publisher := NewEventRouterPublisher(inner, DefaultEventRouterConfigs(kafka.HeaderTopic))
+
+	err := publisher.Publish(context.Background(), core.OutboxMessage{ID: "msg-1"})
+
+	assert.True(t, core.IsPermanentError(err))
+	assert.Empty(t, inner.Calls())
+}
+
+func TestEventRouterPublisher_PublishFailure(t *testing.T) {
+	inner := publishertest.NewPublisher()
+	brokerErr := errors.New("broker unavailable")
+	inner.FailMessage("msg-1", brokerErr)
+	publisher := NewEventRouterPublisher(inner, DefaultEventRouterConfigs(kafka.HeaderTopic))
+
+	err := publisher.Publish(context.Background(), core.OutboxMessage{ID: "msg-1", Headers: map[string]string{core.HeaderAggregateType: "order"}})
+
+	assert.ErrorIs(t, err, brokerErr)
+}
+
+// aggregatePublisher adds the aggregate type the event router needs to messages of the conformance suite.
+type aggregatePublisher struct {
+	*EventRouterPublisher
+}
+
+func (p aggregatePublisher) Publish(ctx context.Context, message core.OutboxMessage) error {
+	headers := map[string]string{core.HeaderAggregateType: "order"}
+	for name, value := range message.Headers {
+		headers[name] = value
+	}
+	message.Headers = headers
+
+	return p.EventRouterPublisher.Publish(ctx, message)
+}
+
+func TestConformance(t *testing.T) {
+	publishertest.Run(t, func(t *testing.T) publishertest.Harness {
+		inner := publishertest.NewPublisher()
+		publisher := NewEventRouterPublisher(inner, DefaultEventRouterConfigs(kafka.HeaderTopic))
+
+		return publishertest.Harness{
+			Publisher: aggregatePublisher{publisher},
+			Received: func() []publishertest.Received {
+				var received []publishertest.Received
+				for _, message := range inner.Published() {
+					received = append(received, publishertest.Received{Payload: message.Payload, Headers: message.Headers})
+				}
+				return received
+			},
+			FailNext: func(err error) {
+				inner.FailCall(len(inner.Calls())+1, err)
+			},
+			// The event router replaces the message headers, as Debezium does.
+			SkipHeaders: true,
+		}
+	})
+}
@@ -0,0 +1,177 @@
+package eventbridge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-transactional-outbox/pkg/core"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+)
+
+// Message headers that override the publisher's defaults for a single event.
+const (
+	HeaderEventBusName = "eventbridge-event-bus-name"
+	HeaderSource       = "eventbridge-source"
+	HeaderDetailType   = "eventbridge-detail-type"
+)
+
+const (
+	MaxBatchSize   = 10         // Maximum number of entries accepted by a single PutEvents request.
+	MaxEntrySize   = 256 * 1024 // Maximum size (in bytes) of a single PutEvents entry.
+	MaxRequestSize = 256 * 1024 // Maximum total size (in bytes) of the entries of a single PutEvents request.
+)
+
+type EventBridgeClient interface {
+	PutEvents(ctx context.Context, params *eventbridge.PutEventsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error)
+}
+
+type EventBridgeConfigs struct {
+	EventBusName string // Event bus used when the message has no bus header. Empty means the default bus.
+	Source       string // Source used when the message has no source header.
+	DetailType   string // Detail type used when the message has no detail-type header.
+}
+
+// EventBridgePublisher sends the payload of every message as the event detail, so payloads must be JSON objects.
+type EventBridgePublisher struct {
+	client  EventBridgeClient
+	configs EventBridgeConfigs
+}
+
+func NewEventBridgeOutboxMessagePublisher(ctx context.Context, configs EventBridgeConfigs) (*EventBridgePublisher, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+
+	client := eventbridge.NewFromConfig(cfg)
+
+	return &EventBridgePublisher{
+		client:  client,
+		configs: configs,
+	}, nil
+}
+
+func (p *EventBridgePublisher) Publish(ctx context.Context, message core.OutboxMessage) error {
+	err := p.PublishBatch(ctx, []core.OutboxMessage{message})
+
+	var batchErr *core.BatchPublishError
+	if errors.As(err, &batchErr) {
+		return batchErr.Errors[message.ID]
+	}
+
+	return err
+}
+
+// PublishBatch sends the messages with PutEvents in requests of up to MaxBatchSize entries and
+// MaxRequestSize bytes. Entries larger than MaxEntrySize are rejected with a permanent error
+// without being sent.
+func (p *EventBridgePublisher) PublishBatch(ctx context.Context, messages []core.OutboxMessage) error {
+	failures := make(map[string]error)
+
+	var pending []core.OutboxMessage
+	var entries []types.PutEventsRequestEntry
+	requestSize := 0
+
+	for _, message := range messages {
+		entry := p.newEntry(message)
+
+		size := entrySize(entry)
+		if size > MaxEntrySize {
+			failures[message.ID] = core.NewPermanentError(
+				fmt.Errorf("event of %d bytes exceeds the EventBridge limit of %d bytes", size, MaxEntrySize),
+			)
+			continue
+		}
+
+		if len(entries) == MaxBatchSize || requestSize+size > MaxRequestSize {
+			p.putEvents(ctx, pending, entries, failures)
+			pending, entries, requestSize = nil, nil, 0
+		}
+
+		pending = append(pending, message)
+		entries = append(entries, entry)
+		requestSize += size
+	}
+
+	if len(entries) > 0 {
+		p.putEvents(ctx, pending, entries, failures)
+	}
+
+	if len(failures) > 0 {
+		return &core.BatchPublishError{Errors: failures}
+	}
+
+	return nil
+}
+
+func (p *EventBridgePublisher) putEvents(ctx context.Context, messages []core.OutboxMessage, entries []types.PutEventsRequestEntry, failures map[string]error) {
+	output, err := p.client.PutEvents(ctx, &eventbridge.PutEventsInput{Entries: entries})
+	if err != nil {
+		for _, message := range messages {
+			failures[message.ID] = fmt.Errorf("failed to put events to EventBridge: %w", err)
+		}
+		return
+	}
+
+	// Result entries are in the same order as the request entries.
+	for i, result := range output.Entries {
+		if i >= len(messages) || result.ErrorCode == nil {
+			continue
+		}
+
+		err := fmt.Errorf(
+			"failed to put event to EventBridge: %s: %s",
+			aws.ToString(result.ErrorCode),
+			aws.ToString(result.ErrorMessage),
+		)
+
+		// A malformed detail is rejected again on every retry.
+		if aws.ToString(result.ErrorCode) == "MalformedDetail" {
+			err = core.NewPermanentError(err)
+		}
+
+		failures[messages[i].ID] = err
+	}
+}
+
+func (p *EventBridgePublisher) newEntry(message core.OutboxMessage) types.PutEventsRequestEntry {
+	entry := types.PutEventsRequestEntry{
+		Source:     aws.String(headerOrDefault(message, HeaderSource, p.configs.Source)),
+		DetailType: aws.String(headerOrDefault(message, HeaderDetailType, p.configs.DetailType)),
+		Detail:     aws.String(message.Payload),
+	}
+
+	if eventBusName := headerOrDefault(message, HeaderEventBusName, p.configs.EventBusName); eventBusName != "" {
+		entry.EventBusName = aws.String(eventBusName)
+	}
+
+	return entry
+}
+
+func headerOrDefault(message core.OutboxMessage, header string, defaultValue string) string {
+	if value := message.Headers[header]; value != "" {
+		return value
+	}
+
+	return defaultValue
+}
+
+// entrySize calculates the size of an entry the way EventBridge does: the source, detail type,
+// detail and resources, plus 14 bytes when a time is set.
+func entrySize(entry types.PutEventsRequestEntry) int {
+	size := len(aws.ToString(entry.Source)) + len(aws.ToString(entry.DetailType)) + len(aws.ToString(entry.Detail))
+
+	if entry.Time != nil {
+		size += 14
+	}
+
+	for _, resource := range entry.Resources {
+		size += len(resource)
+	}
+
+	return size
+}
@@ -0,0 +1,227 @@
+package eventbridge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-transactional-outbox/pkg/core"
+	"go-transactional-outbox/pkg/publisher/publishertest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockEventBridgeClient struct {
+	mock.Mock
+}
+
+func (m *MockEventBridgeClient) PutEvents(ctx context.Context, params *eventbridge.PutEventsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) != nil {
+		return args.Get(0).(*eventbridge.PutEventsOutput), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func successfulEntries(count int) []types.PutEventsResultEntry {
+	entries := make([]types.PutEventsResultEntry, count)
+	for i := range entries {
+		entries[i] = types.PutEventsResultEntry{EventId: aws.String(fmt.Sprint(i))}
+	}
+	return entries
+}
+
+var testConfigs = EventBridgeConfigs{
+	EventBusName: "orders-bus",
+	Source:       "com.example.orders",
+	DetailType:   "OrderEvent",
+}
+
+func TestEventBridgePublisher_Publish_Success(t *testing.T) {
+	mockClient := new(MockEventBridgeClient)
+	publisher := &EventBridgePublisher{
+		client:  mockClient,
+		configs: testConfigs,
+	}
+
+	testMessage := core.OutboxMessage{
+		ID:      "123",
+		Payload: `{"order_id":42}`,
+		Headers: map[string]string{HeaderDetailType: "OrderCreated"},
+		Status:  core.MessageStatusPending,
+	}
+
+	mockClient.On("PutEvents", mock.Anything, mock.MatchedBy(func(input *eventbridge.PutEventsInput) bool {
+		entry := input.Entries[0]
+		return len(input.Entries) == 1 &&
+			*entry.EventBusName == "orders-bus" &&
+			*entry.Source == "com.example.orders" &&
+			*entry.DetailType == "OrderCreated" &&
+			*entry.Detail == `{"order_id":42}`
+	})).Return(&eventbridge.PutEventsOutput{Entries: successfulEntries(1)}, nil)
+
+	err := publisher.Publish(context.Background(), testMessage)
+
+	assert.NoError(t, err)
+	mockClient.AssertExpectations(t)
+}
+
+func TestEventBridgePublisher_Publish_EntryFailure(t *testing.T) {
+	mockClient := new(MockEventBridgeClient)
+	publisher := &EventBridgePublisher{
+		client:  mockClient,
+		configs: testConfigs,
+	}
+
+	mockClient.On("PutEvents", mock.Anything, mock.Anything).Return(&eventbridge.PutEventsOutput{
+		FailedEntryCount: 1,
+		Entries: []types.PutEventsResultEntry{
+			{ErrorCode: aws.String("ThrottlingException"), ErrorMessage: aws.String("Rate exceeded")},
+		},
+	}, nil)
+
+	err := publisher.Publish(context.Background(), core.OutboxMessage{ID: "123", Payload: "{}"})
+
+	assert.EqualError(t, err, "failed to put event to EventBridge: ThrottlingException: Rate exceeded")
+	assert.False(t, core.IsPermanentError(err))
+}
+
+func TestEventBridgePublisher_Publish_OversizedEntryIsPermanent(t *testing.T) {
+	mockClient := new(MockEventBridgeClient)
+	publisher := &EventBridgePublisher{
+		client:  mockClient,
+		configs: testConfigs,
+	}
+
+	payload := `{"data":"` + strings.Repeat("x", MaxEntrySize) + `"}`
+
+	err := publisher.Publish(context.Background(), core.OutboxMessage{ID: "123", Payload: payload})
+
+	require.Error(t, err)
+	assert.True(t, core.IsPermanentError(err))
+	assert.Contains(t, err.Error(), "exceeds the EventBridge limit of 262144 bytes")
+	mockClient.AssertNotCalled(t, "PutEvents", mock.Anything, mock.Anything)
+}
+
+func TestEventBridgePublisher_PublishBatch_ChunksAndReportsFailedEntries(t *testing.T) {
+	mockClient := new(MockEventBridgeClient)
+	publisher := &EventBridgePublisher{
+		client:  mockClient,
+		configs: testConfigs,
+	}
+
+	var messages []core.OutboxMessage
+	for i := 0; i < 12; i++ {
+		messages = append(messages, core.OutboxMessage{ID: fmt.Sprintf("msg-%d", i), Payload: "{}"})
+	}
+
+	firstEntries := successfulEntries(MaxBatchSize)
+	firstEntries[4] = types.PutEventsResultEntry{ErrorCode: aws.String("MalformedDetail"), ErrorMessage: aws.String("Detail is malformed")}
+
+	mockClient.On("PutEvents", mock.Anything, mock.MatchedBy(func(input *eventbridge.PutEventsInput) bool {
+		return len(input.Entries) == MaxBatchSize
+	})).Return(&eventbridge.PutEventsOutput{FailedEntryCount: 1, Entries: firstEntries}, nil).Once()
+	mockClient.On("PutEvents", mock.Anything, mock.MatchedBy(func(input *eventbridge.PutEventsInput) bool {
+		return len(input.Entries) == 2
+	})).Return(nil, errors.New("service unavailable")).Once()
+
+	err := publisher.PublishBatch(context.Background(), messages)
+
+	var batchErr *core.BatchPublishError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Len(t, batchErr.Errors, 3)
+	assert.True(t, core.IsPermanentError(batchErr.Errors["msg-4"]))
+	assert.EqualError(t, batchErr.Errors["msg-10"], "failed to put events to EventBridge: service unavailable")
+	assert.EqualError(t, batchErr.Errors["msg-11"], "failed to put events to EventBridge: service unavailable")
+	mockClient.AssertExpectations(t)
+}
+
+func TestEventBridgePublisher_PublishBatch_ChunksByRequestSize(t *testing.T) {
+	mockClient := new(MockEventBridgeClient)
+	publisher := &EventBridgePublisher{
+		client:  mockClient,
+		configs: testConfigs,
+	}
+
+	// Ten 100KB events fit the entry count of a single request, but only two fit its size.
+	payload := `{"data":"` + strings.Repeat("x", 100*1024) + `"}`
+
+	var messages []core.OutboxMessage
+	for i := 0; i < 10; i++ {
+		messages = append(messages, core.OutboxMessage{ID: fmt.Sprintf("msg-%d", i), Payload: payload})
+	}
+
+	var requestSizes []int
+	mockClient.On("PutEvents", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		input := args.Get(1).(*eventbridge.PutEventsInput)
+
+		size := 0
+		for _, entry := range input.Entries {
+			size += entrySize(entry)
+		}
+		assert.LessOrEqual(t, size, MaxRequestSize)
+
+		requestSizes = append(requestSizes, len(input.Entries))
+	}).Return(&eventbridge.PutEventsOutput{Entries: successfulEntries(2)}, nil)
+
+	require.NoError(t, publisher.PublishBatch(context.Background(), messages))
+
+	assert.Equal(t, []int{2, 2, 2, 2, 2}, requestSizes)
+}
+
+// FakeEventBridgeClient is an in-memory event bus for the conformance suite. Like the SDK, it
+// fails requests whose context is done before sending them.
+type FakeEventBridgeClient struct {
+	mu       sync.Mutex
+	received []publishertest.Received
+	failNext error
+}
+
+func (f *FakeEventBridgeClient) PutEvents(ctx context.Context, params *eventbridge.PutEventsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.failNext; err != nil {
+		f.failNext = nil
+		return nil, err
+	}
+
+	for _, entry := range params.Entries {
+		f.received = append(f.received, publishertest.Received{Payload: aws.ToString(entry.Detail)})
+	}
+
+	return &eventbridge.PutEventsOutput{Entries: successfulEntries(len(params.Entries))}, nil
+}
+
+func TestConformance(t *testing.T) {
+	publishertest.Run(t, func(t *testing.T) publishertest.Harness {
+		client := &FakeEventBridgeClient{}
+
+		return publishertest.Harness{
+			Publisher: &EventBridgePublisher{client: client, configs: testConfigs},
+			Received: func() []publishertest.Received {
+				client.mu.Lock()
+				defer client.mu.Unlock()
+				return append([]publishertest.Received(nil), client.received...)
+			},
+			FailNext: func(err error) {
+				client.mu.Lock()
+				defer client.mu.Unlock()
+				client.failNext = err
+			},
+			// Events carry no attributes; headers only select the source, detail type and bus.
+			SkipHeaders: true,
+		}
+	})
+}
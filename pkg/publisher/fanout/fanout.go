@@ -0,0 +1,91 @@
+package fanout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-transactional-outbox/pkg/core"
+)
+
+type DeliveryPolicy string
+
+const (
+	DeliveryPolicyAll        DeliveryPolicy = "all"         // The message is sent once every destination received it.
+	DeliveryPolicyBestEffort DeliveryPolicy = "best_effort" // The message is sent once any destination received it; failed destinations are not retried.
+)
+
+// Destination is a named publisher. The name identifies the destination in the delivery
+// state, so it must stay stable across deployments.
+type Destination struct {
+	Name      string
+	Publisher core.OutboxMessagePublisher
+}
+
+// FanoutPublisher delivers every message to several destinations. Deliveries are recorded in
+// the repository, so a retried message is only re-sent to the destinations that failed.
+type FanoutPublisher struct {
+	deliveries   core.OutboxDeliveryRepository
+	destinations []Destination
+	policy       DeliveryPolicy
+}
+
+func NewFanoutOutboxMessagePublisher(deliveries core.OutboxDeliveryRepository, policy DeliveryPolicy, destinations ...Destination) *FanoutPublisher {
+	return &FanoutPublisher{
+		deliveries:   deliveries,
+		destinations: destinations,
+		policy:       policy,
+	}
+}
+
+func (p *FanoutPublisher) Publish(ctx context.Context, message core.OutboxMessage) error {
+	delivered, err := p.deliveries.FetchDeliveredDestinations(ctx, message.ID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch delivered destinations: %w", err)
+	}
+
+	isDelivered := make(map[string]bool, len(delivered))
+	for _, name := range delivered {
+		isDelivered[name] = true
+	}
+
+	var failures []error
+
+	for _, destination := range p.destinations {
+		if isDelivered[destination.Name] {
+			continue
+		}
+
+		if err := destination.Publisher.Publish(ctx, message); err != nil {
+			failures = append(failures, fmt.Errorf("destination %s: %w", destination.Name, err))
+			continue
+		}
+
+		if err := p.deliveries.MarkDestinationAsDelivered(ctx, message.ID, destination.Name); err != nil {
+			// Without the record the destination receives the message again on retry, which is
+			// no worse than the outbox's at-least-once guarantee.
+			failures = append(failures, fmt.Errorf("destination %s: failed to record delivery: %w", destination.Name, err))
+			continue
+		}
+
+		isDelivered[destination.Name] = true
+	}
+
+	if len(failures) == 0 {
+		return nil
+	}
+
+	if p.policy == DeliveryPolicyBestEffort && len(isDelivered) > 0 {
+		return nil
+	}
+
+	err = fmt.Errorf("failed to publish message to %d destination(s): %w", len(failures), errors.Join(failures...))
+
+	// Retrying is pointless only when no failed destination can recover.
+	for _, failure := range failures {
+		if !core.IsPermanentError(failure) {
+			return err
+		}
+	}
+
+	return core.NewPermanentError(err)
+}
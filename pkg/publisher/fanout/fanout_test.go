@@ -0,0 +1,180 @@
+package fanout
+
+import (
+	"context"
+	"errors"
+	"go-transactional-outbox/pkg/core"
+	"go-transactional-outbox/pkg/publisher/publishertest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockOutboxMessagePublisher struct {
+	mock.Mock
+}
+
+func (m *MockOutboxMessagePublisher) Publish(ctx context.Context, message core.OutboxMessage) error {
+	args := m.Called(ctx, message)
+	return args.Error(0)
+}
+
+// FakeOutboxDeliveryRepository keeps delivery state in memory.
+type FakeOutboxDeliveryRepository struct {
+	delivered map[string][]string
+}
+
+func NewFakeOutboxDeliveryRepository() *FakeOutboxDeliveryRepository {
+	return &FakeOutboxDeliveryRepository{delivered: make(map[string][]string)}
+}
+
+func (f *FakeOutboxDeliveryRepository) FetchDeliveredDestinations(ctx context.Context, messageID string) ([]string, error) {
+	return f.delivered[messageID], nil
+}
+
+func (f *FakeOutboxDeliveryRepository) MarkDestinationAsDelivered(ctx context.Context, messageID string, destination string) error {
+	f.delivered[messageID] = append(f.delivered[messageID], destination)
+	return nil
+}
+
+func TestFanoutPublisher_Publish_DeliversToAllDestinations(t *testing.T) {
+	sqsPub := new(MockOutboxMessagePublisher)
+	kafkaPub := new(MockOutboxMessagePublisher)
+	deliveries := NewFakeOutboxDeliveryRepository()
+
+	publisher := NewFanoutOutboxMessagePublisher(deliveries, DeliveryPolicyAll,
+		Destination{Name: "sqs", Publisher: sqsPub},
+		Destination{Name: "kafka", Publisher: kafkaPub},
+	)
+
+	ctx := context.Background()
+	message := core.OutboxMessage{ID: "1", Payload: "Test Payload"}
+
+	sqsPub.On("Publish", ctx, message).Return(nil)
+	kafkaPub.On("Publish", ctx, message).Return(nil)
+
+	err := publisher.Publish(ctx, message)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sqs", "kafka"}, deliveries.delivered["1"])
+	sqsPub.AssertExpectations(t)
+	kafkaPub.AssertExpectations(t)
+}
+
+func TestFanoutPublisher_Publish_RetryOnlyResendsToFailedDestinations(t *testing.T) {
+	sqsPub := new(MockOutboxMessagePublisher)
+	kafkaPub := new(MockOutboxMessagePublisher)
+	deliveries := NewFakeOutboxDeliveryRepository()
+
+	publisher := NewFanoutOutboxMessagePublisher(deliveries, DeliveryPolicyAll,
+		Destination{Name: "sqs", Publisher: sqsPub},
+		Destination{Name: "kafka", Publisher: kafkaPub},
+	)
+
+	ctx := context.Background()
+	message := core.OutboxMessage{ID: "1", Payload: "Test Payload"}
+
+	sqsPub.On("Publish", ctx, message).Return(nil).Once()
+	kafkaPub.On("Publish", ctx, message).Return(errors.New("broker unavailable")).Once()
+
+	err := publisher.Publish(ctx, message)
+	require.EqualError(t, err, "failed to publish message to 1 destination(s): destination kafka: broker unavailable")
+	assert.False(t, core.IsPermanentError(err))
+
+	kafkaPub.On("Publish", ctx, message).Return(nil).Once()
+
+	err = publisher.Publish(ctx, message)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"sqs", "kafka"}, deliveries.delivered["1"])
+	sqsPub.AssertNumberOfCalls(t, "Publish", 1)
+	kafkaPub.AssertNumberOfCalls(t, "Publish", 2)
+}
+
+func TestFanoutPublisher_Publish_BestEffortSucceedsWithPartialDelivery(t *testing.T) {
+	sqsPub := new(MockOutboxMessagePublisher)
+	kafkaPub := new(MockOutboxMessagePublisher)
+	deliveries := NewFakeOutboxDeliveryRepository()
+
+	publisher := NewFanoutOutboxMessagePublisher(deliveries, DeliveryPolicyBestEffort,
+		Destination{Name: "sqs", Publisher: sqsPub},
+		Destination{Name: "kafka", Publisher: kafkaPub},
+	)
+
+	ctx := context.Background()
+	message := core.OutboxMessage{ID: "1", Payload: "Test Payload"}
+
+	sqsPub.On("Publish", ctx, message).Return(errors.New("queue does not exist"))
+	kafkaPub.On("Publish", ctx, message).Return(nil)
+
+	err := publisher.Publish(ctx, message)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"kafka"}, deliveries.delivered["1"])
+}
+
+func TestFanoutPublisher_Publish_BestEffortFailsWithoutAnyDelivery(t *testing.T) {
+	sqsPub := new(MockOutboxMessagePublisher)
+	deliveries := NewFakeOutboxDeliveryRepository()
+
+	publisher := NewFanoutOutboxMessagePublisher(deliveries, DeliveryPolicyBestEffort,
+		Destination{Name: "sqs", Publisher: sqsPub},
+	)
+
+	ctx := context.Background()
+	message := core.OutboxMessage{ID: "1", Payload: "Test Payload"}
+
+	sqsPub.On("Publish", ctx, message).Return(errors.New("queue does not exist"))
+
+	err := publisher.Publish(ctx, message)
+
+	assert.Error(t, err)
+}
+
+func TestFanoutPublisher_Publish_PermanentWhenAllFailuresArePermanent(t *testing.T) {
+	sqsPub := new(MockOutboxMessagePublisher)
+	webhookPub := new(MockOutboxMessagePublisher)
+	deliveries := NewFakeOutboxDeliveryRepository()
+
+	publisher := NewFanoutOutboxMessagePublisher(deliveries, DeliveryPolicyAll,
+		Destination{Name: "sqs", Publisher: sqsPub},
+		Destination{Name: "webhook", Publisher: webhookPub},
+	)
+
+	ctx := context.Background()
+	message := core.OutboxMessage{ID: "1", Payload: "Test Payload"}
+
+	sqsPub.On("Publish", ctx, message).Return(nil)
+	webhookPub.On("Publish", ctx, message).Return(core.NewPermanentError(errors.New("status 400")))
+
+	err := publisher.Publish(ctx, message)
+
+	assert.Error(t, err)
+	assert.True(t, core.IsPermanentError(err))
+}
+
+func TestConformance(t *testing.T) {
+	publishertest.Run(t, func(t *testing.T) publishertest.Harness {
+		destination := publishertest.NewPublisher()
+
+		publisher := NewFanoutOutboxMessagePublisher(NewFakeOutboxDeliveryRepository(), DeliveryPolicyAll,
+			Destination{Name: "destination", Publisher: destination},
+		)
+
+		return publishertest.Harness{
+			Publisher: publisher,
+			Received: func() []publishertest.Received {
+				var received []publishertest.Received
+				for _, message := range destination.Published() {
+					received = append(received, publishertest.Received{Payload: message.Payload, Headers: message.Headers})
+				}
+				return received
+			},
+			FailNext: func(err error) {
+				destination.FailCall(len(destination.Calls())+1, err)
+			},
+		}
+	})
+}
@@ -0,0 +1,118 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"go-transactional-outbox/pkg/core"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+const (
+	// HeaderTopic is the message header that overrides the publisher's default topic.
+	// It is not forwarded as a record header.
+	HeaderTopic = "kafka-topic"
+
+	// MessageIDHeader is the record header that carries the outbox message ID.
+	MessageIDHeader = "message-id"
+)
+
+// KafkaClient represents the producer methods of *kgo.Client used by the publisher.
+type KafkaClient interface {
+	ProduceSync(ctx context.Context, rs ...*kgo.Record) kgo.ProduceResults
+	Close()
+}
+
+type KafkaPublisher struct {
+	client KafkaClient
+	topic  string
+}
+
+// NewKafkaOutboxMessagePublisher creates a publisher backed by an idempotent producer that waits
+// for all in-sync replicas to acknowledge every record. Additional client options are applied last.
+func NewKafkaOutboxMessagePublisher(brokers []string, topic string, opts ...kgo.Opt) (*KafkaPublisher, error) {
+	opts = append([]kgo.Opt{
+		kgo.SeedBrokers(brokers...),
+		kgo.RequiredAcks(kgo.AllISRAcks()),
+		kgo.RecordPartitioner(kgo.StickyKeyPartitioner(nil)),
+	}, opts...)
+
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kafka client: %w", err)
+	}
+
+	return &KafkaPublisher{
+		client: client,
+		topic:  topic,
+	}, nil
+}
+
+// Close closes the underlying client. Publish never leaves records buffered, so nothing is lost.
+func (p *KafkaPublisher) Close() {
+	p.client.Close()
+}
+
+// Publish returns once the record is acknowledged by the brokers, so a nil error means it is durable.
+func (p *KafkaPublisher) Publish(ctx context.Context, message core.OutboxMessage) error {
+	if err := p.client.ProduceSync(ctx, p.newRecord(message)).FirstErr(); err != nil {
+		return fmt.Errorf("failed to produce message to Kafka: %w", err)
+	}
+
+	return nil
+}
+
+// PublishBatch produces all messages at once and waits for every acknowledgement.
+// Records sharing a key keep their relative order, as they land on the same partition.
+func (p *KafkaPublisher) PublishBatch(ctx context.Context, messages []core.OutboxMessage) error {
+	records := make([]*kgo.Record, 0, len(messages))
+	messageIDs := make(map[*kgo.Record]string, len(messages))
+
+	for _, message := range messages {
+		record := p.newRecord(message)
+		records = append(records, record)
+		messageIDs[record] = message.ID
+	}
+
+	failures := make(map[string]error)
+
+	// Results are returned in completion order, so they are matched to messages by record.
+	for _, result := range p.client.ProduceSync(ctx, records...) {
+		if result.Err != nil {
+			failures[messageIDs[result.Record]] = fmt.Errorf("failed to produce message to Kafka: %w", result.Err)
+		}
+	}
+
+	if len(failures) > 0 {
+		return &core.BatchPublishError{Errors: failures}
+	}
+
+	return nil
+}
+
+func (p *KafkaPublisher) newRecord(message core.OutboxMessage) *kgo.Record {
+	record := &kgo.Record{
+		Topic: p.topic,
+		Value: []byte(message.Payload),
+		Headers: []kgo.RecordHeader{
+			{Key: MessageIDHeader, Value: []byte(message.ID)},
+		},
+	}
+
+	if message.OrderingKey != "" {
+		record.Key = []byte(message.OrderingKey)
+	}
+
+	for name, value := range message.Headers {
+		if name == HeaderTopic {
+			if value != "" {
+				record.Topic = value
+			}
+			continue
+		}
+
+		record.Headers = append(record.Headers, kgo.RecordHeader{Key: name, Value: []byte(value)})
+	}
+
+	return record
+}
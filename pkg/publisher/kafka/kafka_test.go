@@ -0,0 +1,240 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"go-transactional-outbox/pkg/core"
+	"go-transactional-outbox/pkg/publisher/publishertest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func setupCluster(t *testing.T, topics ...string) []string {
+	cluster, err := kfake.NewCluster(kfake.NumBrokers(1), kfake.SeedTopics(3, topics...))
+	require.NoError(t, err, "Failed to start fake Kafka cluster")
+	t.Cleanup(cluster.Close)
+
+	return cluster.ListenAddrs()
+}
+
+func consume(t *testing.T, brokers []string, topic string, count int) []*kgo.Record {
+	client, err := kgo.NewClient(
+		kgo.SeedBrokers(brokers...),
+		kgo.ConsumeTopics(topic),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()),
+	)
+	require.NoError(t, err)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var records []*kgo.Record
+	for len(records) < count {
+		fetches := client.PollFetches(ctx)
+		require.NoError(t, ctx.Err(), "Timed out waiting for records")
+		records = append(records, fetches.Records()...)
+	}
+
+	return records
+}
+
+func TestKafkaPublisher_Publish_Success(t *testing.T) {
+	brokers := setupCluster(t, "orders")
+
+	publisher, err := NewKafkaOutboxMessagePublisher(brokers, "orders")
+	require.NoError(t, err)
+	t.Cleanup(publisher.Close)
+
+	testMessage := core.OutboxMessage{
+		ID:          "123",
+		Payload:     "Test Payload",
+		Headers:     map[string]string{"event-type": "order.created"},
+		OrderingKey: "order-42",
+		Status:      core.MessageStatusPending,
+	}
+
+	err = publisher.Publish(context.Background(), testMessage)
+	require.NoError(t, err)
+
+	records := consume(t, brokers, "orders", 1)
+	require.Len(t, records, 1)
+
+	headers := make(map[string]string)
+	for _, header := range records[0].Headers {
+		headers[header.Key] = string(header.Value)
+	}
+
+	assert.Equal(t, "Test Payload", string(records[0].Value))
+	assert.Equal(t, "order-42", string(records[0].Key))
+	assert.Equal(t, map[string]string{MessageIDHeader: "123", "event-type": "order.created"}, headers)
+}
+
+func TestKafkaPublisher_PublishBatch_RoutesByTopicAndKeepsKeyOrder(t *testing.T) {
+	brokers := setupCluster(t, "orders", "invoices")
+
+	publisher, err := NewKafkaOutboxMessagePublisher(brokers, "orders")
+	require.NoError(t, err)
+	t.Cleanup(publisher.Close)
+
+	messages := []core.OutboxMessage{
+		{ID: "1", Payload: "first", OrderingKey: "order-42"},
+		{ID: "2", Payload: "invoice", Headers: map[string]string{HeaderTopic: "invoices"}},
+		{ID: "3", Payload: "second", OrderingKey: "order-42"},
+	}
+
+	err = publisher.PublishBatch(context.Background(), messages)
+	require.NoError(t, err)
+
+	orders := consume(t, brokers, "orders", 2)
+	require.Len(t, orders, 2)
+	assert.Equal(t, "first", string(orders[0].Value))
+	assert.Equal(t, "second", string(orders[1].Value))
+
+	invoices := consume(t, brokers, "invoices", 1)
+	require.Len(t, invoices, 1)
+	assert.Equal(t, "invoice", string(invoices[0].Value))
+	for _, header := range invoices[0].Headers {
+		assert.NotEqual(t, HeaderTopic, header.Key)
+	}
+}
+
+// FakeKafkaClient fails the records of selected messages, identified by their message-id header.
+type FakeKafkaClient struct {
+	failures map[string]error
+}
+
+func (f *FakeKafkaClient) ProduceSync(ctx context.Context, rs ...*kgo.Record) kgo.ProduceResults {
+	results := make(kgo.ProduceResults, 0, len(rs))
+
+	// Complete records in reverse to make sure results are not matched by position.
+	for i := len(rs) - 1; i >= 0; i-- {
+		var err error
+		for _, header := range rs[i].Headers {
+			if header.Key == MessageIDHeader {
+				err = f.failures[string(header.Value)]
+			}
+		}
+		results = append(results, kgo.ProduceResult{Record: rs[i], Err: err})
+	}
+
+	return results
+}
+
+func (f *FakeKafkaClient) Close() {}
+
+func TestKafkaPublisher_PublishBatch_PartialFailure(t *testing.T) {
+	publisher := &KafkaPublisher{
+		client: &FakeKafkaClient{failures: map[string]error{"2": errors.New("NOT_ENOUGH_REPLICAS")}},
+		topic:  "orders",
+	}
+
+	err := publisher.PublishBatch(context.Background(), []core.OutboxMessage{
+		{ID: "1", Payload: "first"},
+		{ID: "2", Payload: "second"},
+		{ID: "3", Payload: "third"},
+	})
+
+	var batchErr *core.BatchPublishError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Len(t, batchErr.Errors, 1)
+	assert.EqualError(t, batchErr.Errors["2"], "failed to produce message to Kafka: NOT_ENOUGH_REPLICAS")
+}
+
+func TestKafkaPublisher_Publish_Failure(t *testing.T) {
+	publisher := &KafkaPublisher{
+		client: &FakeKafkaClient{failures: map[string]error{"1": errors.New("NOT_ENOUGH_REPLICAS")}},
+		topic:  "orders",
+	}
+
+	err := publisher.Publish(context.Background(), core.OutboxMessage{ID: "1", Payload: "first"})
+
+	assert.EqualError(t, err, "failed to produce message to Kafka: NOT_ENOUGH_REPLICAS")
+}
+
+// failingKafkaClient fails the next produce request with an injected error before it reaches the brokers.
+type failingKafkaClient struct {
+	KafkaClient
+	mu       sync.Mutex
+	failNext error
+}
+
+func (f *failingKafkaClient) ProduceSync(ctx context.Context, rs ...*kgo.Record) kgo.ProduceResults {
+	f.mu.Lock()
+	err := f.failNext
+	f.failNext = nil
+	f.mu.Unlock()
+
+	if err == nil {
+		return f.KafkaClient.ProduceSync(ctx, rs...)
+	}
+
+	results := make(kgo.ProduceResults, 0, len(rs))
+	for _, record := range rs {
+		results = append(results, kgo.ProduceResult{Record: record, Err: err})
+	}
+
+	return results
+}
+
+func TestConformance(t *testing.T) {
+	publishertest.Run(t, func(t *testing.T) publishertest.Harness {
+		// A single partition keeps the records in the order they were produced.
+		cluster, err := kfake.NewCluster(kfake.NumBrokers(1), kfake.SeedTopics(1, "orders"))
+		require.NoError(t, err, "Failed to start fake Kafka cluster")
+		t.Cleanup(cluster.Close)
+
+		publisher, err := NewKafkaOutboxMessagePublisher(cluster.ListenAddrs(), "orders")
+		require.NoError(t, err)
+		t.Cleanup(publisher.Close)
+
+		client := &failingKafkaClient{KafkaClient: publisher.client}
+		publisher.client = client
+
+		consumer, err := kgo.NewClient(
+			kgo.SeedBrokers(cluster.ListenAddrs()...),
+			kgo.ConsumeTopics("orders"),
+			kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()),
+		)
+		require.NoError(t, err)
+		t.Cleanup(consumer.Close)
+
+		var received []publishertest.Received
+
+		return publishertest.Harness{
+			Publisher: publisher,
+			// Records are acknowledged before Publish returns, so polling until the topic is drained
+			// returns every record produced so far.
+			Received: func() []publishertest.Received {
+				for {
+					ctx, cancel := context.WithTimeout(context.Background(), 250*time.Millisecond)
+					records := consumer.PollFetches(ctx).Records()
+					cancel()
+
+					if len(records) == 0 {
+						return append([]publishertest.Received(nil), received...)
+					}
+
+					for _, record := range records {
+						headers := make(map[string]string, len(record.Headers))
+						for _, header := range record.Headers {
+							headers[header.Key] = string(header.Value)
+						}
+						received = append(received, publishertest.Received{Payload: string(record.Value), Headers: headers})
+					}
+				}
+			},
+			FailNext: func(err error) {
+				client.mu.Lock()
+				defer client.mu.Unlock()
+				client.failNext = err
+			},
+		}
+	})
+}
@@ -0,0 +1,166 @@
+package kinesis
+
+import (
+	"context"
+	"fmt"
+	"go-transactional-outbox/pkg/core"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+)
+
+const (
+	MaxBatchSize      = 500             // Maximum number of records accepted by a single PutRecords request.
+	MaxBatchSizeBytes = 5 * 1024 * 1024 // Maximum size of a PutRecords request, counting data and partition keys.
+	MaxRecordSize     = 1024 * 1024     // Maximum size of a single record, counting data and partition key.
+	MaxPartitionKey   = 256             // Maximum length of a partition key, in Unicode characters.
+)
+
+type KinesisClient interface {
+	PutRecord(ctx context.Context, params *kinesis.PutRecordInput, optFns ...func(*kinesis.Options)) (*kinesis.PutRecordOutput, error)
+	PutRecords(ctx context.Context, params *kinesis.PutRecordsInput, optFns ...func(*kinesis.Options)) (*kinesis.PutRecordsOutput, error)
+}
+
+// KinesisPublisher puts the payload of every message as a record. Kinesis records carry no
+// attributes, so message headers are not forwarded.
+type KinesisPublisher struct {
+	client     KinesisClient
+	streamName string
+}
+
+func NewKinesisOutboxMessagePublisher(ctx context.Context, streamName string) (*KinesisPublisher, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+
+	client := kinesis.NewFromConfig(cfg)
+
+	return &KinesisPublisher{
+		client:     client,
+		streamName: streamName,
+	}, nil
+}
+
+func (p *KinesisPublisher) Publish(ctx context.Context, message core.OutboxMessage) error {
+	if err := validateRecord(message); err != nil {
+		return err
+	}
+
+	input := &kinesis.PutRecordInput{
+		StreamName:   aws.String(p.streamName),
+		PartitionKey: aws.String(partitionKey(message)),
+		Data:         []byte(message.Payload),
+	}
+
+	_, err := p.client.PutRecord(ctx, input)
+	if err != nil {
+		return fmt.Errorf("failed to put record to Kinesis: %w", err)
+	}
+
+	return nil
+}
+
+// PublishBatch puts the messages with PutRecords, splitting them into requests within the
+// Kinesis limits. Records rejected in a partially failed request are reported individually, and
+// records Kinesis would never accept are rejected with a permanent error without being sent.
+func (p *KinesisPublisher) PublishBatch(ctx context.Context, messages []core.OutboxMessage) error {
+	failures := make(map[string]error)
+
+	var pending []core.OutboxMessage
+	size := 0
+
+	for _, message := range messages {
+		if err := validateRecord(message); err != nil {
+			failures[message.ID] = err
+			continue
+		}
+
+		recordSize := len(message.Payload) + len(partitionKey(message))
+
+		if len(pending) == MaxBatchSize || size+recordSize > MaxBatchSizeBytes {
+			p.putRecords(ctx, pending, failures)
+			pending, size = nil, 0
+		}
+
+		pending = append(pending, message)
+		size += recordSize
+	}
+
+	if len(pending) > 0 {
+		p.putRecords(ctx, pending, failures)
+	}
+
+	if len(failures) > 0 {
+		return &core.BatchPublishError{Errors: failures}
+	}
+
+	return nil
+}
+
+func (p *KinesisPublisher) putRecords(ctx context.Context, messages []core.OutboxMessage, failures map[string]error) {
+	entries := make([]types.PutRecordsRequestEntry, 0, len(messages))
+	for _, message := range messages {
+		entries = append(entries, types.PutRecordsRequestEntry{
+			PartitionKey: aws.String(partitionKey(message)),
+			Data:         []byte(message.Payload),
+		})
+	}
+
+	output, err := p.client.PutRecords(ctx, &kinesis.PutRecordsInput{
+		StreamName: aws.String(p.streamName),
+		Records:    entries,
+	})
+	if err != nil {
+		for _, message := range messages {
+			failures[message.ID] = fmt.Errorf("failed to put records to Kinesis: %w", err)
+		}
+		return
+	}
+
+	// Result entries are in the same order as the request entries.
+	for i, record := range output.Records {
+		if i >= len(messages) || record.ErrorCode == nil {
+			continue
+		}
+
+		failures[messages[i].ID] = fmt.Errorf(
+			"failed to put record to Kinesis: %s: %s",
+			aws.ToString(record.ErrorCode),
+			aws.ToString(record.ErrorMessage),
+		)
+	}
+}
+
+// validateRecord rejects messages that Kinesis refuses on every attempt, so they fail at once
+// instead of blocking the rest of a batch.
+func validateRecord(message core.OutboxMessage) error {
+	key := partitionKey(message)
+
+	if length := utf8.RuneCountInString(key); length > MaxPartitionKey {
+		return core.NewPermanentError(
+			fmt.Errorf("partition key of %d characters exceeds the Kinesis limit of %d characters", length, MaxPartitionKey),
+		)
+	}
+
+	if size := len(message.Payload) + len(key); size > MaxRecordSize {
+		return core.NewPermanentError(
+			fmt.Errorf("record of %d bytes exceeds the Kinesis limit of %d bytes", size, MaxRecordSize),
+		)
+	}
+
+	return nil
+}
+
+// partitionKey keeps messages of the same aggregate on the same shard. Messages without an
+// ordering key are spread across shards by their ID.
+func partitionKey(message core.OutboxMessage) string {
+	if message.OrderingKey != "" {
+		return message.OrderingKey
+	}
+
+	return message.ID
+}
@@ -0,0 +1,253 @@
+package kinesis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-transactional-outbox/pkg/core"
+	"go-transactional-outbox/pkg/publisher/publishertest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockKinesisClient struct {
+	mock.Mock
+}
+
+func (m *MockKinesisClient) PutRecord(ctx context.Context, params *kinesis.PutRecordInput, optFns ...func(*kinesis.Options)) (*kinesis.PutRecordOutput, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) != nil {
+		return args.Get(0).(*kinesis.PutRecordOutput), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockKinesisClient) PutRecords(ctx context.Context, params *kinesis.PutRecordsInput, optFns ...func(*kinesis.Options)) (*kinesis.PutRecordsOutput, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) != nil {
+		return args.Get(0).(*kinesis.PutRecordsOutput), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func successfulRecords(count int) []types.PutRecordsResultEntry {
+	records := make([]types.PutRecordsResultEntry, count)
+	for i := range records {
+		records[i] = types.PutRecordsResultEntry{SequenceNumber: aws.String(fmt.Sprint(i)), ShardId: aws.String("shardId-000000000000")}
+	}
+	return records
+}
+
+func TestKinesisPublisher_Publish_Success(t *testing.T) {
+	mockClient := new(MockKinesisClient)
+	publisher := &KinesisPublisher{
+		client:     mockClient,
+		streamName: "orders",
+	}
+
+	testMessage := core.OutboxMessage{
+		ID:          "123",
+		Payload:     "Test Payload",
+		OrderingKey: "order-42",
+		Status:      core.MessageStatusPending,
+	}
+
+	mockClient.On("PutRecord", mock.Anything, mock.MatchedBy(func(input *kinesis.PutRecordInput) bool {
+		return *input.StreamName == "orders" &&
+			*input.PartitionKey == "order-42" &&
+			string(input.Data) == "Test Payload"
+	})).Return(&kinesis.PutRecordOutput{SequenceNumber: aws.String("1")}, nil)
+
+	err := publisher.Publish(context.Background(), testMessage)
+
+	assert.NoError(t, err)
+	mockClient.AssertExpectations(t)
+}
+
+func TestKinesisPublisher_Publish_PartitionsByIDWithoutOrderingKey(t *testing.T) {
+	mockClient := new(MockKinesisClient)
+	publisher := &KinesisPublisher{
+		client:     mockClient,
+		streamName: "orders",
+	}
+
+	mockClient.On("PutRecord", mock.Anything, mock.MatchedBy(func(input *kinesis.PutRecordInput) bool {
+		return *input.PartitionKey == "123"
+	})).Return(nil, errors.New("ProvisionedThroughputExceededException"))
+
+	err := publisher.Publish(context.Background(), core.OutboxMessage{ID: "123", Payload: "Test Payload"})
+
+	assert.EqualError(t, err, "failed to put record to Kinesis: ProvisionedThroughputExceededException")
+	mockClient.AssertExpectations(t)
+}
+
+func TestKinesisPublisher_PublishBatch_ReportsOnlyFailedRecords(t *testing.T) {
+	mockClient := new(MockKinesisClient)
+	publisher := &KinesisPublisher{
+		client:     mockClient,
+		streamName: "orders",
+	}
+
+	messages := []core.OutboxMessage{
+		{ID: "1", Payload: "first", OrderingKey: "order-42"},
+		{ID: "2", Payload: "second", OrderingKey: "order-43"},
+		{ID: "3", Payload: "third", OrderingKey: "order-42"},
+	}
+
+	records := successfulRecords(3)
+	records[1] = types.PutRecordsResultEntry{
+		ErrorCode:    aws.String("ProvisionedThroughputExceededException"),
+		ErrorMessage: aws.String("Rate exceeded for shard"),
+	}
+
+	mockClient.On("PutRecords", mock.Anything, mock.MatchedBy(func(input *kinesis.PutRecordsInput) bool {
+		return len(input.Records) == 3 && *input.Records[1].PartitionKey == "order-43"
+	})).Return(&kinesis.PutRecordsOutput{FailedRecordCount: aws.Int32(1), Records: records}, nil)
+
+	err := publisher.PublishBatch(context.Background(), messages)
+
+	var batchErr *core.BatchPublishError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Len(t, batchErr.Errors, 1)
+	assert.EqualError(t, batchErr.Errors["2"], "failed to put record to Kinesis: ProvisionedThroughputExceededException: Rate exceeded for shard")
+	mockClient.AssertExpectations(t)
+}
+
+func TestKinesisPublisher_PublishBatch_SplitsRequestsAtLimits(t *testing.T) {
+	client := &recordingKinesisClient{}
+	publisher := &KinesisPublisher{
+		client:     client,
+		streamName: "orders",
+	}
+
+	var messages []core.OutboxMessage
+	for i := 0; i < MaxBatchSize+1; i++ {
+		messages = append(messages, core.OutboxMessage{ID: fmt.Sprint(i), Payload: "small"})
+	}
+
+	// Five records of 1MB fit in a request next to the leftover small one; the sixth does not.
+	for i := 0; i < 6; i++ {
+		messages = append(messages, core.OutboxMessage{ID: fmt.Sprintf("large-%d", i), Payload: strings.Repeat("x", 1000*1000)})
+	}
+
+	err := publisher.PublishBatch(context.Background(), messages)
+
+	require.NoError(t, err)
+	assert.Equal(t, []int{MaxBatchSize, 6, 1}, client.requestSizes)
+}
+
+func TestKinesisPublisher_Publish_RejectsOversizedRecords(t *testing.T) {
+	mockClient := new(MockKinesisClient)
+	publisher := &KinesisPublisher{
+		client:     mockClient,
+		streamName: "orders",
+	}
+
+	err := publisher.Publish(context.Background(), core.OutboxMessage{ID: "1", Payload: "{}", OrderingKey: strings.Repeat("k", MaxPartitionKey+1)})
+	assert.EqualError(t, err, "partition key of 257 characters exceeds the Kinesis limit of 256 characters")
+	assert.True(t, core.IsPermanentError(err))
+
+	err = publisher.Publish(context.Background(), core.OutboxMessage{ID: "2", Payload: strings.Repeat("x", MaxRecordSize)})
+	assert.EqualError(t, err, "record of 1048577 bytes exceeds the Kinesis limit of 1048576 bytes")
+	assert.True(t, core.IsPermanentError(err))
+
+	mockClient.AssertNotCalled(t, "PutRecord", mock.Anything, mock.Anything)
+}
+
+func TestKinesisPublisher_PublishBatch_RejectsOversizedRecordsWithoutBlockingSiblings(t *testing.T) {
+	client := &recordingKinesisClient{}
+	publisher := &KinesisPublisher{
+		client:     client,
+		streamName: "orders",
+	}
+
+	messages := []core.OutboxMessage{
+		{ID: "1", Payload: "small"},
+		{ID: "2", Payload: "small", OrderingKey: strings.Repeat("ü", MaxPartitionKey+1)},
+		{ID: "3", Payload: strings.Repeat("x", MaxRecordSize)},
+		{ID: "4", Payload: "small", OrderingKey: strings.Repeat("ü", MaxPartitionKey)},
+	}
+
+	err := publisher.PublishBatch(context.Background(), messages)
+
+	var batchErr *core.BatchPublishError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Len(t, batchErr.Errors, 2)
+	assert.True(t, core.IsPermanentError(batchErr.Errors["2"]))
+	assert.True(t, core.IsPermanentError(batchErr.Errors["3"]))
+	assert.Equal(t, []int{2}, client.requestSizes)
+}
+
+// recordingKinesisClient accepts every record and remembers the size of each PutRecords request.
+type recordingKinesisClient struct {
+	requestSizes []int
+}
+
+func (c *recordingKinesisClient) PutRecord(ctx context.Context, params *kinesis.PutRecordInput, optFns ...func(*kinesis.Options)) (*kinesis.PutRecordOutput, error) {
+	return &kinesis.PutRecordOutput{}, nil
+}
+
+func (c *recordingKinesisClient) PutRecords(ctx context.Context, params *kinesis.PutRecordsInput, optFns ...func(*kinesis.Options)) (*kinesis.PutRecordsOutput, error) {
+	c.requestSizes = append(c.requestSizes, len(params.Records))
+	return &kinesis.PutRecordsOutput{FailedRecordCount: aws.Int32(0), Records: successfulRecords(len(params.Records))}, nil
+}
+
+// FakeKinesisClient is an in-memory stream for the conformance suite. Like the SDK, it fails
+// requests whose context is done before sending them.
+type FakeKinesisClient struct {
+	mu       sync.Mutex
+	received []publishertest.Received
+	failNext error
+}
+
+func (f *FakeKinesisClient) PutRecord(ctx context.Context, params *kinesis.PutRecordInput, optFns ...func(*kinesis.Options)) (*kinesis.PutRecordOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.failNext; err != nil {
+		f.failNext = nil
+		return nil, err
+	}
+
+	f.received = append(f.received, publishertest.Received{Payload: string(params.Data)})
+
+	return &kinesis.PutRecordOutput{ShardId: aws.String("shardId-000000000000"), SequenceNumber: aws.String(fmt.Sprint(len(f.received)))}, nil
+}
+
+func (f *FakeKinesisClient) PutRecords(ctx context.Context, params *kinesis.PutRecordsInput, optFns ...func(*kinesis.Options)) (*kinesis.PutRecordsOutput, error) {
+	return nil, errors.New("PutRecords is not supported by FakeKinesisClient")
+}
+
+func TestConformance(t *testing.T) {
+	publishertest.Run(t, func(t *testing.T) publishertest.Harness {
+		client := &FakeKinesisClient{}
+
+		return publishertest.Harness{
+			Publisher: &KinesisPublisher{client: client, streamName: "orders"},
+			Received: func() []publishertest.Received {
+				client.mu.Lock()
+				defer client.mu.Unlock()
+				return append([]publishertest.Received(nil), client.received...)
+			},
+			FailNext: func(err error) {
+				client.mu.Lock()
+				defer client.mu.Unlock()
+				client.failNext = err
+			},
+			// Kinesis records carry no attributes.
+			SkipHeaders: true,
+		}
+	})
+}
@@ -0,0 +1,77 @@
+package nats
+
+import (
+	"context"
+	"fmt"
+	"go-transactional-outbox/pkg/core"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// HeaderSubject is the message header that overrides the publisher's default subject.
+// It is not forwarded as a NATS header.
+const HeaderSubject = "nats-subject"
+
+// JetStreamClient represents the methods of jetstream.JetStream used by the publisher.
+type JetStreamClient interface {
+	PublishMsg(ctx context.Context, msg *nats.Msg, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error)
+}
+
+type NATSPublisher struct {
+	js      JetStreamClient
+	conn    *nats.Conn
+	subject string
+}
+
+func NewNATSOutboxMessagePublisher(url string, subject string, opts ...nats.Option) (*NATSPublisher, error) {
+	conn, err := nats.Connect(url, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create JetStream context: %w", err)
+	}
+
+	return &NATSPublisher{
+		js:      js,
+		conn:    conn,
+		subject: subject,
+	}, nil
+}
+
+// Close closes the connection opened by NewNATSOutboxMessagePublisher.
+func (p *NATSPublisher) Close() {
+	if p.conn != nil {
+		p.conn.Close()
+	}
+}
+
+// Publish waits for the stream's PubAck. The outbox message ID is sent as Nats-Msg-Id, so a
+// message published again after a crash is discarded by the stream's duplicate window and
+// acknowledged as a duplicate, which counts as a successful publish.
+func (p *NATSPublisher) Publish(ctx context.Context, message core.OutboxMessage) error {
+	msg := nats.NewMsg(p.subject)
+	msg.Data = []byte(message.Payload)
+
+	for name, value := range message.Headers {
+		if name == HeaderSubject {
+			if value != "" {
+				msg.Subject = value
+			}
+			continue
+		}
+
+		msg.Header.Set(name, value)
+	}
+
+	_, err := p.js.PublishMsg(ctx, msg, jetstream.WithMsgID(message.ID))
+	if err != nil {
+		return fmt.Errorf("failed to publish message to JetStream: %w", err)
+	}
+
+	return nil
+}
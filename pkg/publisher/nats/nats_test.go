@@ -0,0 +1,171 @@
+package nats
+
+import (
+	"context"
+	"go-transactional-outbox/pkg/core"
+	"go-transactional-outbox/pkg/publisher/publishertest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupJetStream starts an embedded NATS server with an "ORDERS" stream bound to "orders.>".
+func setupJetStream(t *testing.T) (string, jetstream.Stream) {
+	ns, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      server.RANDOM_PORT,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	require.NoError(t, err, "Failed to create embedded NATS server")
+
+	go ns.Start()
+	t.Cleanup(ns.Shutdown)
+	require.True(t, ns.ReadyForConnections(5*time.Second), "Embedded NATS server did not start")
+
+	conn, err := nats.Connect(ns.ClientURL())
+	require.NoError(t, err)
+	t.Cleanup(conn.Close)
+
+	js, err := jetstream.New(conn)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+
+	stream, err := js.CreateStream(ctx, jetstream.StreamConfig{
+		Name:       "ORDERS",
+		Subjects:   []string{"orders.>"},
+		Duplicates: time.Minute,
+	})
+	require.NoError(t, err)
+
+	return ns.ClientURL(), stream
+}
+
+func TestNATSPublisher_Publish_Success(t *testing.T) {
+	url, stream := setupJetStream(t)
+	ctx := context.Background()
+
+	publisher, err := NewNATSOutboxMessagePublisher(url, "orders.events")
+	require.NoError(t, err)
+	t.Cleanup(publisher.Close)
+
+	testMessage := core.OutboxMessage{
+		ID:      "123",
+		Payload: "Test Payload",
+		Headers: map[string]string{HeaderSubject: "orders.created", "Event-Type": "order.created"},
+		Status:  core.MessageStatusPending,
+	}
+
+	err = publisher.Publish(ctx, testMessage)
+	require.NoError(t, err)
+
+	stored, err := stream.GetLastMsgForSubject(ctx, "orders.created")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Test Payload", string(stored.Data))
+	assert.Equal(t, "123", stored.Header.Get(jetstream.MsgIDHeader))
+	assert.Equal(t, "order.created", stored.Header.Get("Event-Type"))
+	assert.Empty(t, stored.Header.Get(HeaderSubject))
+}
+
+func TestNATSPublisher_Publish_DeduplicatesRepublishedMessage(t *testing.T) {
+	url, stream := setupJetStream(t)
+	ctx := context.Background()
+
+	publisher, err := NewNATSOutboxMessagePublisher(url, "orders.events")
+	require.NoError(t, err)
+	t.Cleanup(publisher.Close)
+
+	testMessage := core.OutboxMessage{ID: "123", Payload: "Test Payload"}
+
+	// The second publish simulates a crash between publishing and marking the message as sent.
+	require.NoError(t, publisher.Publish(ctx, testMessage))
+	require.NoError(t, publisher.Publish(ctx, testMessage))
+
+	info, err := stream.Info(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), info.State.Msgs)
+}
+
+func TestNATSPublisher_Publish_NoStreamForSubject(t *testing.T) {
+	url, _ := setupJetStream(t)
+
+	publisher, err := NewNATSOutboxMessagePublisher(url, "payments.events")
+	require.NoError(t, err)
+	t.Cleanup(publisher.Close)
+
+	err = publisher.Publish(context.Background(), core.OutboxMessage{ID: "123", Payload: "Test Payload"})
+
+	assert.ErrorIs(t, err, jetstream.ErrNoStreamResponse)
+}
+
+// failingJetStreamClient fails the next publish with an injected error before it reaches the server.
+type failingJetStreamClient struct {
+	JetStreamClient
+	mu       sync.Mutex
+	failNext error
+}
+
+func (f *failingJetStreamClient) PublishMsg(ctx context.Context, msg *nats.Msg, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error) {
+	f.mu.Lock()
+	err := f.failNext
+	f.failNext = nil
+	f.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return f.JetStreamClient.PublishMsg(ctx, msg, opts...)
+}
+
+func TestConformance(t *testing.T) {
+	publishertest.Run(t, func(t *testing.T) publishertest.Harness {
+		url, stream := setupJetStream(t)
+
+		publisher, err := NewNATSOutboxMessagePublisher(url, "orders.events")
+		require.NoError(t, err)
+		t.Cleanup(publisher.Close)
+
+		client := &failingJetStreamClient{JetStreamClient: publisher.js}
+		publisher.js = client
+
+		return publishertest.Harness{
+			Publisher: publisher,
+			Received: func() []publishertest.Received {
+				ctx := context.Background()
+
+				info, err := stream.Info(ctx)
+				require.NoError(t, err)
+
+				var received []publishertest.Received
+				for seq := info.State.FirstSeq; seq > 0 && seq <= info.State.LastSeq; seq++ {
+					stored, err := stream.GetMsg(ctx, seq)
+					require.NoError(t, err)
+
+					headers := make(map[string]string, len(stored.Header))
+					for name := range stored.Header {
+						headers[name] = stored.Header.Get(name)
+					}
+					received = append(received, publishertest.Received{Payload: string(stored.Data), Headers: headers})
+				}
+				return received
+			},
+			FailNext: func(err error) {
+				client.mu.Lock()
+				defer client.mu.Unlock()
+				client.failNext = err
+			},
+		}
+	})
+}
@@ -0,0 +1,141 @@
+package publishertest
+
+import (
+	"context"
+	"errors"
+	"go-transactional-outbox/pkg/core"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Received is a message as the broker received it.
+type Received struct {
+	Payload string
+	Headers map[string]string // Header names are compared as they are, so the suite only uses lowercase names.
+}
+
+// Harness connects the conformance suite to a publisher and to the fake broker behind it.
+type Harness struct {
+	Publisher core.OutboxMessagePublisher
+
+	// Received returns the messages the broker received, in order of arrival.
+	Received func() []Received
+
+	// FailNext makes the broker reject the next request with err. Leave it nil when the
+	// publisher cannot be made to see a broker error; the error wrapping test is skipped then.
+	FailNext func(err error)
+
+	// SkipHeaders skips the header mapping test, for brokers without message headers and
+	// publishers that do not forward them.
+	SkipHeaders bool
+}
+
+// Factory returns a harness around a new publisher and an empty broker. It is called once per test.
+type Factory func(t *testing.T) Harness
+
+// Run runs the conformance suite against the publishers created by factory.
+func Run(t *testing.T, factory Factory) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, h Harness)
+	}{
+		{"Publish", testPublish},
+		{"Publish_MapsHeaders", testPublishMapsHeaders},
+		{"Publish_CanceledContext", testPublishCanceledContext},
+		{"Publish_ExpiredContext", testPublishExpiredContext},
+		{"Publish_WrapsBrokerErrors", testPublishWrapsBrokerErrors},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, factory(t))
+		})
+	}
+}
+
+func testPublish(t *testing.T, h Harness) {
+	ctx := context.Background()
+
+	require.NoError(t, h.Publisher.Publish(ctx, core.OutboxMessage{ID: "1", Payload: "Payload 1"}))
+	require.NoError(t, h.Publisher.Publish(ctx, core.OutboxMessage{ID: "2", Payload: "Payload 2"}))
+
+	received := h.Received()
+	require.Len(t, received, 2)
+	assert.Equal(t, "Payload 1", received[0].Payload)
+	assert.Equal(t, "Payload 2", received[1].Payload)
+}
+
+func testPublishMapsHeaders(t *testing.T, h Harness) {
+	if h.SkipHeaders {
+		t.Skip("Publisher does not forward message headers")
+	}
+
+	headers := map[string]string{
+		"event-type": "order.created",
+		"trace-id":   "4bf92f3577b34da6",
+	}
+
+	err := h.Publisher.Publish(context.Background(), core.OutboxMessage{ID: "1", Payload: "Payload", Headers: headers})
+	require.NoError(t, err)
+
+	received := h.Received()
+	require.Len(t, received, 1)
+
+	// Publishers may add headers of their own, but must not drop or alter the message's.
+	for name, value := range headers {
+		assert.Equal(t, value, received[0].Headers[name], "Header %s was not mapped", name)
+	}
+}
+
+func testPublishCanceledContext(t *testing.T, h Harness) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := h.Publisher.Publish(ctx, core.OutboxMessage{ID: "1", Payload: "Payload"})
+
+	assertContextError(t, h, err, context.Canceled)
+}
+
+func testPublishExpiredContext(t *testing.T, h Harness) {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	err := h.Publisher.Publish(ctx, core.OutboxMessage{ID: "1", Payload: "Payload"})
+
+	assertContextError(t, h, err, context.DeadlineExceeded)
+}
+
+func testPublishWrapsBrokerErrors(t *testing.T, h Harness) {
+	if h.FailNext == nil {
+		t.Skip("Harness cannot inject broker errors")
+	}
+
+	ctx := context.Background()
+	brokerErr := errors.New("broker unavailable")
+
+	h.FailNext(brokerErr)
+
+	err := h.Publisher.Publish(ctx, core.OutboxMessage{ID: "1", Payload: "Payload 1"})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, brokerErr, "Broker errors must be wrapped, not replaced")
+	assert.False(t, core.IsPermanentError(err), "Transient broker errors must be retried")
+
+	// The publisher must recover from the failure.
+	require.NoError(t, h.Publisher.Publish(ctx, core.OutboxMessage{ID: "2", Payload: "Payload 2"}))
+
+	received := h.Received()
+	require.NotEmpty(t, received)
+	assert.Equal(t, "Payload 2", received[len(received)-1].Payload)
+}
+
+// assertContextError checks that a publish with a done context fails with a retryable error
+// that wraps the context's error, without reaching the broker.
+func assertContextError(t *testing.T, h Harness, err error, target error) {
+	require.Error(t, err)
+	assert.ErrorIs(t, err, target)
+	assert.False(t, core.IsPermanentError(err), "Context errors must be retried")
+	assert.Empty(t, h.Received(), "Message must not reach the broker")
+}
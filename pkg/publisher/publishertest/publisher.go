@@ -0,0 +1,186 @@
+// Package publishertest provides a recording fake core.OutboxMessagePublisher and a conformance
+// suite for publisher implementations.
+package publishertest
+
+import (
+	"context"
+	"go-transactional-outbox/pkg/core"
+	"sync"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Call is a single Publish call recorded by Publisher.
+type Call struct {
+	Message core.OutboxMessage
+	Err     error
+}
+
+// Publisher is a fake publisher that records every call, with scripted failures and latency.
+// It is safe for concurrent use, so it can back dispatchers running several workers.
+type Publisher struct {
+	mu            sync.Mutex
+	calls         []Call
+	callCount     int
+	messageErrors map[string][]error
+	callErrors    map[int]error
+	latency       time.Duration
+}
+
+func NewPublisher() *Publisher {
+	return &Publisher{
+		messageErrors: make(map[string][]error),
+		callErrors:    make(map[int]error),
+	}
+}
+
+// FailMessage makes the next publishes of a message fail with errs, in order. Once they are
+// used up, the message is published successfully.
+func (p *Publisher) FailMessage(id string, errs ...error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.messageErrors[id] = append(p.messageErrors[id], errs...)
+}
+
+// FailCall makes the n-th Publish call fail with err, counting from 1.
+func (p *Publisher) FailCall(n int, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.callErrors[n] = err
+}
+
+// SetLatency delays every publish by d, or until the context is done.
+func (p *Publisher) SetLatency(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.latency = d
+}
+
+func (p *Publisher) Publish(ctx context.Context, message core.OutboxMessage) error {
+	p.mu.Lock()
+	p.callCount++
+	err := p.scriptedError(p.callCount, message.ID)
+	latency := p.latency
+	p.mu.Unlock()
+
+	if ctxErr := wait(ctx, latency); ctxErr != nil {
+		err = ctxErr
+	}
+
+	message.Headers = copyHeaders(message.Headers)
+
+	p.mu.Lock()
+	p.calls = append(p.calls, Call{Message: message, Err: err})
+	p.mu.Unlock()
+
+	return err
+}
+
+// Calls returns every recorded call in order of completion, including failed ones.
+func (p *Publisher) Calls() []Call {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]Call(nil), p.calls...)
+}
+
+// Published returns the successfully published messages in order of completion.
+func (p *Publisher) Published() []core.OutboxMessage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var messages []core.OutboxMessage
+	for _, call := range p.calls {
+		if call.Err == nil {
+			messages = append(messages, call.Message)
+		}
+	}
+
+	return messages
+}
+
+// AssertPublishedInOrder asserts that exactly the given messages were published, in this order.
+func (p *Publisher) AssertPublishedInOrder(t assert.TestingT, ids ...string) bool {
+	published := make([]string, 0, len(ids))
+	for _, message := range p.Published() {
+		published = append(published, message.ID)
+	}
+
+	return assert.Equal(t, ids, published, "Published messages do not match")
+}
+
+// AssertPublishedHeaders asserts that a message was published with exactly the given headers.
+func (p *Publisher) AssertPublishedHeaders(t assert.TestingT, id string, headers map[string]string) bool {
+	for _, message := range p.Published() {
+		if message.ID == id {
+			return assert.Equal(t, headers, message.Headers, "Headers of message %s do not match", id)
+		}
+	}
+
+	return assert.Fail(t, "Message was not published", "Message %s was not published", id)
+}
+
+// AssertNotPublished asserts that a message was never published successfully.
+func (p *Publisher) AssertNotPublished(t assert.TestingT, id string) bool {
+	for _, message := range p.Published() {
+		if message.ID == id {
+			return assert.Fail(t, "Message was published", "Message %s was published", id)
+		}
+	}
+
+	return true
+}
+
+// scriptedError returns the error scripted for a call, preferring call number scripts. It must
+// be called with the mutex held.
+func (p *Publisher) scriptedError(n int, id string) error {
+	if err, ok := p.callErrors[n]; ok {
+		delete(p.callErrors, n)
+		return err
+	}
+
+	if errs := p.messageErrors[id]; len(errs) > 0 {
+		p.messageErrors[id] = errs[1:]
+		return errs[0]
+	}
+
+	return nil
+}
+
+// wait blocks for d and returns the context's error if it is done first, or already done.
+func wait(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func copyHeaders(headers map[string]string) map[string]string {
+	if headers == nil {
+		return nil
+	}
+
+	copied := make(map[string]string, len(headers))
+	for name, value := range headers {
+		copied[name] = value
+	}
+
+	return copied
+}
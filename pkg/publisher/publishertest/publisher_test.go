@@ -0,0 +1,118 @@
+package publishertest
+
+import (
+	"context"
+	"errors"
+	"go-transactional-outbox/pkg/core"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublisher_Publish_RecordsCalls(t *testing.T) {
+	publisher := NewPublisher()
+	ctx := context.Background()
+
+	headers := map[string]string{"event-type": "order.created"}
+
+	require.NoError(t, publisher.Publish(ctx, core.OutboxMessage{ID: "1", Payload: "Payload 1", Headers: headers}))
+	require.NoError(t, publisher.Publish(ctx, core.OutboxMessage{ID: "2", Payload: "Payload 2"}))
+
+	// Recorded messages must not change with the caller's headers.
+	headers["event-type"] = "changed"
+
+	publisher.AssertPublishedInOrder(t, "1", "2")
+	publisher.AssertPublishedHeaders(t, "1", map[string]string{"event-type": "order.created"})
+	publisher.AssertNotPublished(t, "3")
+	assert.Len(t, publisher.Calls(), 2)
+}
+
+func TestPublisher_FailMessage(t *testing.T) {
+	publisher := NewPublisher()
+	ctx := context.Background()
+
+	first, second := errors.New("first"), errors.New("second")
+	publisher.FailMessage("1", first, second)
+
+	assert.Equal(t, first, publisher.Publish(ctx, core.OutboxMessage{ID: "1"}))
+	assert.NoError(t, publisher.Publish(ctx, core.OutboxMessage{ID: "2"}))
+	assert.Equal(t, second, publisher.Publish(ctx, core.OutboxMessage{ID: "1"}))
+	assert.NoError(t, publisher.Publish(ctx, core.OutboxMessage{ID: "1"}))
+
+	publisher.AssertPublishedInOrder(t, "2", "1")
+
+	calls := publisher.Calls()
+	require.Len(t, calls, 4)
+	assert.Equal(t, first, calls[0].Err)
+	assert.Equal(t, second, calls[2].Err)
+}
+
+func TestPublisher_FailCall(t *testing.T) {
+	publisher := NewPublisher()
+	ctx := context.Background()
+
+	callErr := errors.New("call failed")
+	publisher.FailCall(2, callErr)
+
+	assert.NoError(t, publisher.Publish(ctx, core.OutboxMessage{ID: "1"}))
+	assert.Equal(t, callErr, publisher.Publish(ctx, core.OutboxMessage{ID: "2"}))
+	assert.NoError(t, publisher.Publish(ctx, core.OutboxMessage{ID: "2"}))
+
+	publisher.AssertPublishedInOrder(t, "1", "2")
+}
+
+func TestPublisher_SetLatency(t *testing.T) {
+	publisher := NewPublisher()
+	publisher.SetLatency(50 * time.Millisecond)
+
+	start := time.Now()
+	require.NoError(t, publisher.Publish(context.Background(), core.OutboxMessage{ID: "1"}))
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := publisher.Publish(ctx, core.OutboxMessage{ID: "2"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	publisher.AssertNotPublished(t, "2")
+}
+
+func TestPublisher_ConcurrentPublishes(t *testing.T) {
+	publisher := NewPublisher()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = publisher.Publish(context.Background(), core.OutboxMessage{ID: "1"})
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Len(t, publisher.Published(), 20)
+}
+
+func TestConformance(t *testing.T) {
+	Run(t, func(t *testing.T) Harness {
+		publisher := NewPublisher()
+
+		return Harness{
+			Publisher: publisher,
+			Received: func() []Received {
+				var received []Received
+				for _, message := range publisher.Published() {
+					received = append(received, Received{Payload: message.Payload, Headers: message.Headers})
+				}
+				return received
+			},
+			FailNext: func(err error) {
+				publisher.FailCall(len(publisher.Calls())+1, err)
+			},
+		}
+	})
+}
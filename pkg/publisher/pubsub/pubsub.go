@@ -0,0 +1,107 @@
+package pubsub
+
+import (
+	"context"
+	"fmt"
+	"go-transactional-outbox/pkg/core"
+	"sync"
+
+	"cloud.google.com/go/pubsub"
+	"google.golang.org/api/option"
+)
+
+// HeaderTopic is the message header that overrides the publisher's default topic.
+// It is not forwarded as a message attribute.
+const HeaderTopic = "pubsub-topic"
+
+type PubSubPublisher struct {
+	client  *pubsub.Client
+	topicID string
+
+	mu     sync.Mutex
+	topics map[string]*pubsub.Topic
+}
+
+// NewPubSubOutboxMessagePublisher creates a publisher for the given project. The client honours
+// PUBSUB_EMULATOR_HOST, so it can be pointed at the Pub/Sub emulator without extra options.
+func NewPubSubOutboxMessagePublisher(ctx context.Context, projectID string, topicID string, opts ...option.ClientOption) (*PubSubPublisher, error) {
+	client, err := pubsub.NewClient(ctx, projectID, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Pub/Sub client: %w", err)
+	}
+
+	return &PubSubPublisher{
+		client:  client,
+		topicID: topicID,
+		topics:  make(map[string]*pubsub.Topic),
+	}, nil
+}
+
+// Publish blocks until the server returns the message ID. Messages with an ordering key are
+// published in order; when one of them fails, publishing is resumed for the key so that the
+// dispatcher's retry of that message is not rejected.
+func (p *PubSubPublisher) Publish(ctx context.Context, message core.OutboxMessage) error {
+	topicID := p.topicID
+	attributes := map[string]string{"MessageID": message.ID}
+
+	for name, value := range message.Headers {
+		if name == HeaderTopic {
+			if value != "" {
+				topicID = value
+			}
+			continue
+		}
+
+		attributes[name] = value
+	}
+
+	// Topic.Publish sends the message in the background regardless of ctx, so a done context
+	// must be caught here.
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("failed to publish message to Pub/Sub: %w", err)
+	}
+
+	topic := p.topic(topicID)
+
+	result := topic.Publish(ctx, &pubsub.Message{
+		Data:        []byte(message.Payload),
+		Attributes:  attributes,
+		OrderingKey: message.OrderingKey,
+	})
+
+	if _, err := result.Get(ctx); err != nil {
+		if message.OrderingKey != "" {
+			topic.ResumePublish(message.OrderingKey)
+		}
+
+		return fmt.Errorf("failed to publish message to Pub/Sub: %w", err)
+	}
+
+	return nil
+}
+
+// Close flushes and stops every topic used by the publisher and closes the client.
+func (p *PubSubPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, topic := range p.topics {
+		topic.Stop()
+	}
+
+	return p.client.Close()
+}
+
+func (p *PubSubPublisher) topic(topicID string) *pubsub.Topic {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	topic, ok := p.topics[topicID]
+	if !ok {
+		topic = p.client.Topic(topicID)
+		topic.EnableMessageOrdering = true
+		p.topics[topicID] = topic
+	}
+
+	return topic
+}
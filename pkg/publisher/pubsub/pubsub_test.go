@@ -0,0 +1,118 @@
+package pubsub
+
+import (
+	"context"
+	"go-transactional-outbox/pkg/core"
+	"go-transactional-outbox/pkg/publisher/publishertest"
+	"testing"
+
+	"cloud.google.com/go/pubsub"
+	"cloud.google.com/go/pubsub/apiv1/pubsubpb"
+	"cloud.google.com/go/pubsub/pstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+const testProjectID = "test-project"
+
+// setupPubSub starts an in-process Pub/Sub fake with the given topics and returns a publisher connected to it.
+func setupPubSub(t *testing.T, topicIDs ...string) (*pstest.Server, *PubSubPublisher) {
+	ctx := context.Background()
+
+	server := pstest.NewServer()
+	t.Cleanup(func() { _ = server.Close() })
+
+	conn, err := grpc.NewClient(server.Addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+
+	publisher, err := NewPubSubOutboxMessagePublisher(ctx, testProjectID, topicIDs[0], option.WithGRPCConn(conn))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = publisher.Close() })
+
+	for _, topicID := range topicIDs {
+		_, err := publisher.client.CreateTopic(ctx, topicID)
+		require.NoError(t, err)
+	}
+
+	return server, publisher
+}
+
+func TestPubSubPublisher_Publish_Success(t *testing.T) {
+	server, publisher := setupPubSub(t, "orders", "invoices")
+
+	testMessage := core.OutboxMessage{
+		ID:          "123",
+		Payload:     "Test Payload",
+		Headers:     map[string]string{HeaderTopic: "invoices", "event-type": "invoice.created"},
+		OrderingKey: "customer-42",
+		Status:      core.MessageStatusPending,
+	}
+
+	err := publisher.Publish(context.Background(), testMessage)
+	require.NoError(t, err)
+
+	messages := server.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, "Test Payload", string(messages[0].Data))
+	assert.Equal(t, "customer-42", messages[0].OrderingKey)
+	assert.Equal(t, map[string]string{"MessageID": "123", "event-type": "invoice.created"}, messages[0].Attributes)
+}
+
+func TestPubSubPublisher_Publish_ResumesOrderingKeyAfterError(t *testing.T) {
+	server, publisher := setupPubSub(t, "orders")
+	ctx := context.Background()
+
+	server.SetAutoPublishResponse(false)
+	server.AddPublishResponse(nil, status.Error(codes.PermissionDenied, "publish denied"))
+	server.AddPublishResponse(&pubsubpb.PublishResponse{MessageIds: []string{"server-1"}}, nil)
+
+	first := core.OutboxMessage{ID: "1", Payload: "first", OrderingKey: "customer-42"}
+
+	err := publisher.Publish(ctx, first)
+	assert.ErrorContains(t, err, "failed to publish message to Pub/Sub")
+
+	var paused pubsub.ErrPublishingPaused
+	assert.NotErrorAs(t, err, &paused)
+
+	// The retry of the same message must go through instead of failing as paused.
+	err = publisher.Publish(ctx, first)
+	assert.NoError(t, err)
+}
+
+func TestPubSubPublisher_Publish_UnknownTopic(t *testing.T) {
+	_, publisher := setupPubSub(t, "orders")
+
+	err := publisher.Publish(context.Background(), core.OutboxMessage{
+		ID:      "123",
+		Payload: "Test Payload",
+		Headers: map[string]string{HeaderTopic: "missing"},
+	})
+
+	assert.ErrorContains(t, err, "failed to publish message to Pub/Sub")
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestConformance(t *testing.T) {
+	publishertest.Run(t, func(t *testing.T) publishertest.Harness {
+		server, publisher := setupPubSub(t, "orders")
+
+		// FailNext is left nil: the publisher uses a concrete *pubsub.Client, which reports broker
+		// failures as gRPC statuses rather than the injected error. Those failures are covered by
+		// TestPubSubPublisher_Publish_ResumesOrderingKeyAfterError and TestPubSubPublisher_Publish_UnknownTopic.
+		return publishertest.Harness{
+			Publisher: publisher,
+			Received: func() []publishertest.Received {
+				var received []publishertest.Received
+				for _, message := range server.Messages() {
+					received = append(received, publishertest.Received{Payload: string(message.Data), Headers: message.Attributes})
+				}
+				return received
+			},
+		}
+	})
+}
@@ -0,0 +1,116 @@
+package redisstream
+
+import (
+	"context"
+	"fmt"
+	"go-transactional-outbox/pkg/core"
+	"sort"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// HeaderStream is the message header that overrides the publisher's default stream.
+// It is not stored as a stream field.
+const HeaderStream = "redis-stream"
+
+// Stream fields that hold the outbox message. Headers are stored as additional fields with their own names.
+const (
+	FieldMessageID = "message_id"
+	FieldPayload   = "payload"
+)
+
+// RedisClient represents the methods of redis.UniversalClient used by the publisher.
+type RedisClient interface {
+	XAdd(ctx context.Context, a *redis.XAddArgs) *redis.StringCmd
+	Pipelined(ctx context.Context, fn func(redis.Pipeliner) error) ([]redis.Cmder, error)
+}
+
+type RedisStreamConfigs struct {
+	Stream string // Stream that messages are added to, unless overridden per message.
+	MaxLen int64  // Approximate maximum length of a stream (MAXLEN ~). Zero disables trimming.
+}
+
+type RedisStreamPublisher struct {
+	client  RedisClient
+	configs RedisStreamConfigs
+}
+
+func NewRedisStreamOutboxMessagePublisher(client RedisClient, configs RedisStreamConfigs) *RedisStreamPublisher {
+	return &RedisStreamPublisher{
+		client:  client,
+		configs: configs,
+	}
+}
+
+func (p *RedisStreamPublisher) Publish(ctx context.Context, message core.OutboxMessage) error {
+	if err := p.client.XAdd(ctx, p.newXAddArgs(message)).Err(); err != nil {
+		return fmt.Errorf("failed to add message to Redis stream: %w", err)
+	}
+
+	return nil
+}
+
+// PublishBatch adds all messages in a single pipeline round trip.
+func (p *RedisStreamPublisher) PublishBatch(ctx context.Context, messages []core.OutboxMessage) error {
+	cmds := make([]*redis.StringCmd, len(messages))
+
+	_, err := p.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, message := range messages {
+			cmds[i] = pipe.XAdd(ctx, p.newXAddArgs(message))
+		}
+		return nil
+	})
+
+	// The pipeline error is the first failed command's, so every command is checked on its own.
+	failures := make(map[string]error)
+
+	for i, cmd := range cmds {
+		if cmd == nil {
+			failures[messages[i].ID] = fmt.Errorf("failed to add message to Redis stream: %w", err)
+			continue
+		}
+
+		if cmdErr := cmd.Err(); cmdErr != nil {
+			failures[messages[i].ID] = fmt.Errorf("failed to add message to Redis stream: %w", cmdErr)
+		}
+	}
+
+	if len(failures) > 0 {
+		return &core.BatchPublishError{Errors: failures}
+	}
+
+	return nil
+}
+
+func (p *RedisStreamPublisher) newXAddArgs(message core.OutboxMessage) *redis.XAddArgs {
+	stream := p.configs.Stream
+
+	names := make([]string, 0, len(message.Headers))
+	for name, value := range message.Headers {
+		if name == HeaderStream {
+			if value != "" {
+				stream = value
+			}
+			continue
+		}
+
+		if name != FieldMessageID && name != FieldPayload {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	values := make([]interface{}, 0, 4+2*len(names))
+	values = append(values, FieldMessageID, message.ID, FieldPayload, message.Payload)
+	for _, name := range names {
+		values = append(values, name, message.Headers[name])
+	}
+
+	return &redis.XAddArgs{
+		Stream: stream,
+		MaxLen: p.configs.MaxLen,
+		Approx: p.configs.MaxLen > 0,
+		Values: values,
+	}
+}
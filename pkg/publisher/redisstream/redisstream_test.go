@@ -0,0 +1,153 @@
+package redisstream
+
+import (
+	"context"
+	"fmt"
+	"go-transactional-outbox/pkg/core"
+	"go-transactional-outbox/pkg/publisher/publishertest"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	server := miniredis.RunT(t)
+
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	return server, client
+}
+
+func TestRedisStreamPublisher_Publish_Success(t *testing.T) {
+	_, client := setupRedis(t)
+	ctx := context.Background()
+
+	publisher := NewRedisStreamOutboxMessagePublisher(client, RedisStreamConfigs{Stream: "outbox"})
+
+	testMessage := core.OutboxMessage{
+		ID:      "123",
+		Payload: "Test Payload",
+		Headers: map[string]string{"event-type": "order.created", HeaderStream: "orders"},
+		Status:  core.MessageStatusPending,
+	}
+
+	err := publisher.Publish(ctx, testMessage)
+	require.NoError(t, err)
+
+	entries, err := client.XRange(ctx, "orders", "-", "+").Result()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	assert.Equal(t, map[string]interface{}{
+		FieldMessageID: "123",
+		FieldPayload:   "Test Payload",
+		"event-type":   "order.created",
+	}, entries[0].Values)
+}
+
+func TestRedisStreamPublisher_Publish_TrimsStream(t *testing.T) {
+	_, client := setupRedis(t)
+	ctx := context.Background()
+
+	publisher := NewRedisStreamOutboxMessagePublisher(client, RedisStreamConfigs{Stream: "outbox", MaxLen: 3})
+
+	for i := 0; i < 5; i++ {
+		err := publisher.Publish(ctx, core.OutboxMessage{ID: fmt.Sprint(i), Payload: "Test Payload"})
+		require.NoError(t, err)
+	}
+
+	length, err := client.XLen(ctx, "outbox").Result()
+	require.NoError(t, err)
+	// Redis trims approximate limits lazily; the in-memory server applies them exactly.
+	assert.Equal(t, int64(3), length)
+}
+
+func TestRedisStreamPublisher_Publish_Failure(t *testing.T) {
+	server, client := setupRedis(t)
+	ctx := context.Background()
+
+	// A plain key with the stream's name makes XADD fail with WRONGTYPE.
+	require.NoError(t, server.Set("outbox", "not a stream"))
+
+	publisher := NewRedisStreamOutboxMessagePublisher(client, RedisStreamConfigs{Stream: "outbox"})
+
+	err := publisher.Publish(ctx, core.OutboxMessage{ID: "123", Payload: "Test Payload"})
+
+	assert.ErrorContains(t, err, "failed to add message to Redis stream: WRONGTYPE")
+}
+
+func TestRedisStreamPublisher_PublishBatch_PartialFailure(t *testing.T) {
+	server, client := setupRedis(t)
+	ctx := context.Background()
+
+	require.NoError(t, server.Set("broken", "not a stream"))
+
+	publisher := NewRedisStreamOutboxMessagePublisher(client, RedisStreamConfigs{Stream: "outbox"})
+
+	err := publisher.PublishBatch(ctx, []core.OutboxMessage{
+		{ID: "1", Payload: "first"},
+		{ID: "2", Payload: "second", Headers: map[string]string{HeaderStream: "broken"}},
+		{ID: "3", Payload: "third"},
+	})
+
+	var batchErr *core.BatchPublishError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Len(t, batchErr.Errors, 1)
+	assert.ErrorContains(t, batchErr.Errors["2"], "WRONGTYPE")
+
+	entries, err := client.XRange(ctx, "outbox", "-", "+").Result()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "1", entries[0].Values[FieldMessageID])
+	assert.Equal(t, "3", entries[1].Values[FieldMessageID])
+}
+
+// failingRedisClient fails the next XADD with an injected error before it reaches the server.
+type failingRedisClient struct {
+	RedisClient
+	failNext error
+}
+
+func (f *failingRedisClient) XAdd(ctx context.Context, a *redis.XAddArgs) *redis.StringCmd {
+	if err := f.failNext; err != nil {
+		f.failNext = nil
+
+		cmd := redis.NewStringCmd(ctx)
+		cmd.SetErr(err)
+		return cmd
+	}
+
+	return f.RedisClient.XAdd(ctx, a)
+}
+
+func TestConformance(t *testing.T) {
+	publishertest.Run(t, func(t *testing.T) publishertest.Harness {
+		_, redisClient := setupRedis(t)
+		client := &failingRedisClient{RedisClient: redisClient}
+
+		return publishertest.Harness{
+			Publisher: NewRedisStreamOutboxMessagePublisher(client, RedisStreamConfigs{Stream: "outbox"}),
+			Received: func() []publishertest.Received {
+				entries, err := redisClient.XRange(context.Background(), "outbox", "-", "+").Result()
+				require.NoError(t, err)
+
+				var received []publishertest.Received
+				for _, entry := range entries {
+					headers := make(map[string]string, len(entry.Values))
+					for name, value := range entry.Values {
+						headers[name] = fmt.Sprint(value)
+					}
+					received = append(received, publishertest.Received{Payload: headers[FieldPayload], Headers: headers})
+				}
+				return received
+			},
+			FailNext: func(err error) {
+				client.failNext = err
+			},
+		}
+	})
+}
@@ -0,0 +1,227 @@
+package sns
+
+import (
+	"context"
+	"fmt"
+	"go-transactional-outbox/pkg/core"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sns/types"
+)
+
+// Message headers that control how a message is published. They are not forwarded as message attributes.
+const (
+	HeaderTopicARN        = "sns-topic-arn"                // Overrides the publisher's default topic.
+	HeaderSubject         = "sns-subject"                  // Subject used for email subscriptions.
+	HeaderMessageGroupID  = "sns-message-group-id"         // Ordering group of a FIFO topic.
+	HeaderDeduplicationID = "sns-message-deduplication-id" // Deduplication ID of a FIFO topic (defaults to the message ID).
+)
+
+// MaxBatchSize is the maximum number of entries accepted by a single PublishBatch request.
+const MaxBatchSize = 10
+
+type SNSClient interface {
+	Publish(ctx context.Context, params *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error)
+	PublishBatch(ctx context.Context, params *sns.PublishBatchInput, optFns ...func(*sns.Options)) (*sns.PublishBatchOutput, error)
+}
+
+type SNSPublisher struct {
+	client   SNSClient
+	topicARN string
+}
+
+func NewSNSOutboxMessagePublisher(ctx context.Context, topicARN string) (*SNSPublisher, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+
+	client := sns.NewFromConfig(cfg)
+
+	return &SNSPublisher{
+		client:   client,
+		topicARN: topicARN,
+	}, nil
+}
+
+func (p *SNSPublisher) Publish(ctx context.Context, message core.OutboxMessage) error {
+	topicARN := p.resolveTopicARN(message)
+
+	input := &sns.PublishInput{
+		TopicArn:          aws.String(topicARN),
+		Message:           aws.String(message.Payload),
+		MessageAttributes: messageAttributes(message),
+	}
+
+	if subject := message.Headers[HeaderSubject]; subject != "" {
+		input.Subject = aws.String(subject)
+	}
+
+	if isFIFOTopic(topicARN) {
+		input.MessageGroupId, input.MessageDeduplicationId = fifoIDs(message)
+	}
+
+	_, err := p.client.Publish(ctx, input)
+	if err != nil {
+		return fmt.Errorf("failed to publish message to SNS: %w", err)
+	}
+
+	return nil
+}
+
+// PublishBatch publishes the messages with as few PublishBatch requests as possible.
+// Messages are grouped by topic and sent in chunks of MaxBatchSize.
+func (p *SNSPublisher) PublishBatch(ctx context.Context, messages []core.OutboxMessage) error {
+	failures := make(map[string]error)
+
+	for _, topicARN := range p.topicOrder(messages) {
+		var topicMessages []core.OutboxMessage
+		for _, message := range messages {
+			if p.resolveTopicARN(message) == topicARN {
+				topicMessages = append(topicMessages, message)
+			}
+		}
+
+		for start := 0; start < len(topicMessages); start += MaxBatchSize {
+			end := min(start+MaxBatchSize, len(topicMessages))
+			p.publishChunk(ctx, topicARN, topicMessages[start:end], failures)
+		}
+	}
+
+	if len(failures) > 0 {
+		return &core.BatchPublishError{Errors: failures}
+	}
+
+	return nil
+}
+
+func (p *SNSPublisher) publishChunk(ctx context.Context, topicARN string, messages []core.OutboxMessage, failures map[string]error) {
+	entries := make([]types.PublishBatchRequestEntry, 0, len(messages))
+
+	// Batch entry IDs only allow a restricted character set, so the position in the chunk is used instead of the message ID.
+	for i, message := range messages {
+		entry := types.PublishBatchRequestEntry{
+			Id:                aws.String(strconv.Itoa(i)),
+			Message:           aws.String(message.Payload),
+			MessageAttributes: messageAttributes(message),
+		}
+
+		if subject := message.Headers[HeaderSubject]; subject != "" {
+			entry.Subject = aws.String(subject)
+		}
+
+		if isFIFOTopic(topicARN) {
+			entry.MessageGroupId, entry.MessageDeduplicationId = fifoIDs(message)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	output, err := p.client.PublishBatch(ctx, &sns.PublishBatchInput{
+		TopicArn:                   aws.String(topicARN),
+		PublishBatchRequestEntries: entries,
+	})
+	if err != nil {
+		for _, message := range messages {
+			failures[message.ID] = fmt.Errorf("failed to publish message batch to SNS: %w", err)
+		}
+		return
+	}
+
+	for _, failed := range output.Failed {
+		i, err := strconv.Atoi(aws.ToString(failed.Id))
+		if err != nil || i < 0 || i >= len(messages) {
+			continue
+		}
+
+		failures[messages[i].ID] = fmt.Errorf(
+			"failed to publish message to SNS: %s: %s",
+			aws.ToString(failed.Code),
+			aws.ToString(failed.Message),
+		)
+	}
+}
+
+func (p *SNSPublisher) resolveTopicARN(message core.OutboxMessage) string {
+	if topicARN := message.Headers[HeaderTopicARN]; topicARN != "" {
+		return topicARN
+	}
+
+	return p.topicARN
+}
+
+// topicOrder returns the distinct topics of the messages in the order they first appear.
+func (p *SNSPublisher) topicOrder(messages []core.OutboxMessage) []string {
+	seen := make(map[string]bool)
+
+	var topics []string
+	for _, message := range messages {
+		topicARN := p.resolveTopicARN(message)
+		if !seen[topicARN] {
+			seen[topicARN] = true
+			topics = append(topics, topicARN)
+		}
+	}
+
+	return topics
+}
+
+func messageAttributes(message core.OutboxMessage) map[string]types.MessageAttributeValue {
+	attributes := map[string]types.MessageAttributeValue{
+		"MessageID": {
+			DataType:    aws.String("String"),
+			StringValue: aws.String(message.ID),
+		},
+	}
+
+	// SNS rejects String attributes with an empty value, so empty headers are left out.
+	for name, value := range message.Headers {
+		if isControlHeader(name) || value == "" {
+			continue
+		}
+
+		attributes[name] = types.MessageAttributeValue{
+			DataType:    aws.String("String"),
+			StringValue: aws.String(value),
+		}
+	}
+
+	return attributes
+}
+
+// fifoIDs returns the message group and deduplication IDs of a message published to a FIFO topic.
+// The group defaults to the ordering key, so messages of a key are delivered in order. Messages
+// without either get their own group, which keeps them publishable but unordered.
+func fifoIDs(message core.OutboxMessage) (*string, *string) {
+	groupID := message.Headers[HeaderMessageGroupID]
+	if groupID == "" {
+		groupID = message.OrderingKey
+	}
+	if groupID == "" {
+		groupID = message.ID
+	}
+
+	deduplicationID := message.Headers[HeaderDeduplicationID]
+	if deduplicationID == "" {
+		deduplicationID = message.ID
+	}
+
+	return aws.String(groupID), aws.String(deduplicationID)
+}
+
+func isFIFOTopic(topicARN string) bool {
+	return strings.HasSuffix(topicARN, ".fifo")
+}
+
+func isControlHeader(name string) bool {
+	switch name {
+	case HeaderTopicARN, HeaderSubject, HeaderMessageGroupID, HeaderDeduplicationID:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,252 @@
+package sns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-transactional-outbox/pkg/core"
+	"go-transactional-outbox/pkg/publisher/publishertest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sns/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockSNSClient struct {
+	mock.Mock
+}
+
+func (m *MockSNSClient) Publish(ctx context.Context, params *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) != nil {
+		return args.Get(0).(*sns.PublishOutput), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockSNSClient) PublishBatch(ctx context.Context, params *sns.PublishBatchInput, optFns ...func(*sns.Options)) (*sns.PublishBatchOutput, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) != nil {
+		return args.Get(0).(*sns.PublishBatchOutput), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+const testTopicARN = "arn:aws:sns:us-east-1:123456789012:orders"
+
+func TestSNSPublisher_Publish_Success(t *testing.T) {
+	mockClient := new(MockSNSClient)
+	publisher := &SNSPublisher{
+		client:   mockClient,
+		topicARN: testTopicARN,
+	}
+
+	testMessage := core.OutboxMessage{
+		ID:      "123",
+		Payload: "Test Payload",
+		Headers: map[string]string{"event-type": "order.created", HeaderSubject: "Order created"},
+		Status:  core.MessageStatusPending,
+	}
+
+	mockClient.On("Publish", mock.Anything, mock.MatchedBy(func(input *sns.PublishInput) bool {
+		_, hasSubjectAttribute := input.MessageAttributes[HeaderSubject]
+		return *input.TopicArn == testTopicARN &&
+			*input.Message == "Test Payload" &&
+			*input.Subject == "Order created" &&
+			*input.MessageAttributes["MessageID"].StringValue == "123" &&
+			*input.MessageAttributes["event-type"].StringValue == "order.created" &&
+			!hasSubjectAttribute &&
+			input.MessageGroupId == nil
+	})).Return(&sns.PublishOutput{MessageId: aws.String("msg-123")}, nil)
+
+	err := publisher.Publish(context.Background(), testMessage)
+
+	assert.NoError(t, err)
+	mockClient.AssertExpectations(t)
+}
+
+func TestSNSPublisher_Publish_FIFOTopicOverride(t *testing.T) {
+	mockClient := new(MockSNSClient)
+	publisher := &SNSPublisher{
+		client:   mockClient,
+		topicARN: testTopicARN,
+	}
+
+	testMessage := core.OutboxMessage{
+		ID:      "123",
+		Payload: "Test Payload",
+		Headers: map[string]string{
+			HeaderTopicARN:       "arn:aws:sns:us-east-1:123456789012:payments.fifo",
+			HeaderMessageGroupID: "customer-42",
+		},
+	}
+
+	mockClient.On("Publish", mock.Anything, mock.MatchedBy(func(input *sns.PublishInput) bool {
+		return *input.TopicArn == "arn:aws:sns:us-east-1:123456789012:payments.fifo" &&
+			*input.MessageGroupId == "customer-42" &&
+			*input.MessageDeduplicationId == "123"
+	})).Return(&sns.PublishOutput{MessageId: aws.String("msg-123")}, nil)
+
+	err := publisher.Publish(context.Background(), testMessage)
+
+	assert.NoError(t, err)
+	mockClient.AssertExpectations(t)
+}
+
+func TestFIFOIDs_GroupFallsBackToOrderingKey(t *testing.T) {
+	groupID, deduplicationID := fifoIDs(core.OutboxMessage{ID: "123", OrderingKey: "order-42"})
+	assert.Equal(t, "order-42", *groupID)
+	assert.Equal(t, "123", *deduplicationID)
+
+	groupID, _ = fifoIDs(core.OutboxMessage{ID: "123", OrderingKey: "order-42", Headers: map[string]string{HeaderMessageGroupID: "customer-7"}})
+	assert.Equal(t, "customer-7", *groupID, "The group header takes precedence over the ordering key")
+
+	groupID, _ = fifoIDs(core.OutboxMessage{ID: "123"})
+	assert.Equal(t, "123", *groupID)
+}
+
+func TestMessageAttributes_SkipsEmptyHeaders(t *testing.T) {
+	attributes := messageAttributes(core.OutboxMessage{ID: "123", Headers: map[string]string{"event-type": "order.created", "trace-id": ""}})
+
+	assert.Contains(t, attributes, "event-type")
+	assert.NotContains(t, attributes, "trace-id", "SNS rejects empty String attributes")
+}
+
+func TestSNSPublisher_Publish_Failure(t *testing.T) {
+	mockClient := new(MockSNSClient)
+	publisher := &SNSPublisher{
+		client:   mockClient,
+		topicARN: testTopicARN,
+	}
+
+	fakeAwsSnsErrorMessage := "failed to publish"
+	mockClient.On("Publish", mock.Anything, mock.Anything).Return(nil, errors.New(fakeAwsSnsErrorMessage))
+
+	err := publisher.Publish(context.Background(), core.OutboxMessage{ID: "123", Payload: "Test Payload"})
+
+	assert.EqualError(t, err, fmt.Sprintf("failed to publish message to SNS: %s", fakeAwsSnsErrorMessage))
+	mockClient.AssertExpectations(t)
+}
+
+func TestSNSPublisher_PublishBatch_ChunksAndReportsFailedEntries(t *testing.T) {
+	mockClient := new(MockSNSClient)
+	publisher := &SNSPublisher{
+		client:   mockClient,
+		topicARN: testTopicARN,
+	}
+
+	var messages []core.OutboxMessage
+	for i := 0; i < 12; i++ {
+		messages = append(messages, core.OutboxMessage{ID: fmt.Sprintf("msg-%d", i), Payload: "Test Payload"})
+	}
+
+	mockClient.On("PublishBatch", mock.Anything, mock.MatchedBy(func(input *sns.PublishBatchInput) bool {
+		return len(input.PublishBatchRequestEntries) == MaxBatchSize
+	})).Return(&sns.PublishBatchOutput{
+		Failed: []types.BatchResultErrorEntry{
+			{Id: aws.String(strconv.Itoa(3)), Code: aws.String("InternalError"), Message: aws.String("try again")},
+		},
+	}, nil).Once()
+	mockClient.On("PublishBatch", mock.Anything, mock.MatchedBy(func(input *sns.PublishBatchInput) bool {
+		return len(input.PublishBatchRequestEntries) == 2
+	})).Return(&sns.PublishBatchOutput{}, nil).Once()
+
+	err := publisher.PublishBatch(context.Background(), messages)
+
+	var batchErr *core.BatchPublishError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Len(t, batchErr.Errors, 1)
+	assert.EqualError(t, batchErr.Errors["msg-3"], "failed to publish message to SNS: InternalError: try again")
+	mockClient.AssertExpectations(t)
+}
+
+func TestSNSPublisher_PublishBatch_GroupsByTopic(t *testing.T) {
+	mockClient := new(MockSNSClient)
+	publisher := &SNSPublisher{
+		client:   mockClient,
+		topicARN: testTopicARN,
+	}
+
+	otherTopicARN := "arn:aws:sns:us-east-1:123456789012:invoices"
+	messages := []core.OutboxMessage{
+		{ID: "1", Payload: "Order"},
+		{ID: "2", Payload: "Invoice", Headers: map[string]string{HeaderTopicARN: otherTopicARN}},
+		{ID: "3", Payload: "Order"},
+	}
+
+	mockClient.On("PublishBatch", mock.Anything, mock.MatchedBy(func(input *sns.PublishBatchInput) bool {
+		return *input.TopicArn == testTopicARN && len(input.PublishBatchRequestEntries) == 2
+	})).Return(&sns.PublishBatchOutput{}, nil).Once()
+	mockClient.On("PublishBatch", mock.Anything, mock.MatchedBy(func(input *sns.PublishBatchInput) bool {
+		return *input.TopicArn == otherTopicARN && len(input.PublishBatchRequestEntries) == 1
+	})).Return(nil, errors.New("throttled")).Once()
+
+	err := publisher.PublishBatch(context.Background(), messages)
+
+	var batchErr *core.BatchPublishError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Len(t, batchErr.Errors, 1)
+	assert.EqualError(t, batchErr.Errors["2"], "failed to publish message batch to SNS: throttled")
+	mockClient.AssertExpectations(t)
+}
+
+// FakeSNSClient is an in-memory topic for the conformance suite. Like the SDK, it fails
+// requests whose context is done before sending them.
+type FakeSNSClient struct {
+	mu       sync.Mutex
+	received []publishertest.Received
+	failNext error
+}
+
+func (f *FakeSNSClient) Publish(ctx context.Context, params *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.failNext; err != nil {
+		f.failNext = nil
+		return nil, err
+	}
+
+	headers := make(map[string]string, len(params.MessageAttributes))
+	for name, attribute := range params.MessageAttributes {
+		headers[name] = aws.ToString(attribute.StringValue)
+	}
+
+	f.received = append(f.received, publishertest.Received{Payload: aws.ToString(params.Message), Headers: headers})
+
+	return &sns.PublishOutput{MessageId: aws.String(strconv.Itoa(len(f.received)))}, nil
+}
+
+func (f *FakeSNSClient) PublishBatch(ctx context.Context, params *sns.PublishBatchInput, optFns ...func(*sns.Options)) (*sns.PublishBatchOutput, error) {
+	return nil, errors.New("PublishBatch is not supported by FakeSNSClient")
+}
+
+func TestConformance(t *testing.T) {
+	publishertest.Run(t, func(t *testing.T) publishertest.Harness {
+		client := &FakeSNSClient{}
+
+		return publishertest.Harness{
+			Publisher: &SNSPublisher{client: client, topicARN: testTopicARN},
+			Received: func() []publishertest.Received {
+				client.mu.Lock()
+				defer client.mu.Unlock()
+				return append([]publishertest.Received(nil), client.received...)
+			},
+			FailNext: func(err error) {
+				client.mu.Lock()
+				defer client.mu.Unlock()
+				client.failNext = err
+			},
+		}
+	})
+}
@@ -0,0 +1,153 @@
+package sqs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+const (
+	// MaxMessageSize is the maximum size (in bytes) of an SQS message, including its attributes.
+	MaxMessageSize = 256 * 1024
+
+	// ExtendedPayloadSizeAttribute is the message attribute holding the size of an offloaded payload.
+	// It is the attribute name used by the AWS extended client libraries.
+	ExtendedPayloadSizeAttribute = "ExtendedPayloadSize"
+
+	s3PointerClass = "software.amazon.payloadoffloading.PayloadS3Pointer"
+)
+
+// S3Client represents the S3 methods used to offload and resolve large payloads.
+type S3Client interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+type ClaimCheckConfigs struct {
+	Bucket    string // S3 bucket that stores offloaded payloads.
+	KeyPrefix string // Prefix prepended to the S3 object key (the outbox message ID).
+	Threshold int    // Message size (in bytes) above which the payload is offloaded to S3.
+}
+
+func DefaultClaimCheckConfigs(bucket string) ClaimCheckConfigs {
+	return ClaimCheckConfigs{
+		Bucket:    bucket,
+		KeyPrefix: "",
+		Threshold: MaxMessageSize,
+	}
+}
+
+// PayloadS3Pointer is the reference sent in place of an offloaded payload.
+type PayloadS3Pointer struct {
+	S3BucketName string `json:"s3BucketName"`
+	S3Key        string `json:"s3Key"`
+}
+
+type claimCheck struct {
+	client  S3Client
+	configs ClaimCheckConfigs
+}
+
+func (c *claimCheck) shouldOffload(input *sqs.SendMessageInput) bool {
+	return messageSize(input) > c.configs.Threshold
+}
+
+// offload uploads the message body to S3 and replaces it with a pointer in the
+// format of the AWS extended client libraries. The object key is derived from the
+// outbox message ID, so a retried message overwrites its own object.
+func (c *claimCheck) offload(ctx context.Context, messageID string, input *sqs.SendMessageInput) error {
+	payload := aws.ToString(input.MessageBody)
+	key := c.configs.KeyPrefix + messageID
+
+	_, err := c.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(c.configs.Bucket),
+		Key:    aws.String(key),
+		Body:   strings.NewReader(payload),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload payload to S3: %w", err)
+	}
+
+	body, err := json.Marshal([]interface{}{
+		s3PointerClass,
+		PayloadS3Pointer{S3BucketName: c.configs.Bucket, S3Key: key},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode S3 pointer: %w", err)
+	}
+
+	input.MessageBody = aws.String(string(body))
+	input.MessageAttributes[ExtendedPayloadSizeAttribute] = types.MessageAttributeValue{
+		DataType:    aws.String("Number"),
+		StringValue: aws.String(strconv.Itoa(len(payload))),
+	}
+
+	return nil
+}
+
+// ParsePayloadS3Pointer reports whether the message body is a pointer to an offloaded payload.
+func ParsePayloadS3Pointer(body string) (PayloadS3Pointer, bool) {
+	var parts []json.RawMessage
+	if err := json.Unmarshal([]byte(body), &parts); err != nil || len(parts) != 2 {
+		return PayloadS3Pointer{}, false
+	}
+
+	var class string
+	if err := json.Unmarshal(parts[0], &class); err != nil || class != s3PointerClass {
+		return PayloadS3Pointer{}, false
+	}
+
+	var pointer PayloadS3Pointer
+	if err := json.Unmarshal(parts[1], &pointer); err != nil || pointer.S3BucketName == "" || pointer.S3Key == "" {
+		return PayloadS3Pointer{}, false
+	}
+
+	return pointer, true
+}
+
+// ResolvePayload returns the original payload of a received message body. Bodies that
+// are not S3 pointers are returned as they are, so consumers can call it for every message.
+func ResolvePayload(ctx context.Context, client S3Client, body string) (string, error) {
+	pointer, ok := ParsePayloadS3Pointer(body)
+	if !ok {
+		return body, nil
+	}
+
+	output, err := client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(pointer.S3BucketName),
+		Key:    aws.String(pointer.S3Key),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to download payload from S3: %w", err)
+	}
+
+	defer output.Body.Close()
+
+	payload, err := io.ReadAll(output.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read payload from S3: %w", err)
+	}
+
+	return string(payload), nil
+}
+
+// messageSize calculates the size of a message the way SQS does: the body plus the
+// name, data type and value of every message attribute.
+func messageSize(input *sqs.SendMessageInput) int {
+	size := len(aws.ToString(input.MessageBody))
+
+	for name, attribute := range input.MessageAttributes {
+		size += len(name) + len(aws.ToString(attribute.DataType))
+		size += len(aws.ToString(attribute.StringValue)) + len(attribute.BinaryValue)
+	}
+
+	return size
+}
@@ -0,0 +1,90 @@
+//go:build integration
+
+package sqs
+
+import (
+	"context"
+	"errors"
+	"go-transactional-outbox/pkg/core"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestS3Client connects to the MinIO server of docker-compose.yml, or to the S3-compatible
+// endpoint in OUTBOX_TEST_S3_ENDPOINT. The test is skipped when the server is unavailable.
+func newTestS3Client(t *testing.T) *s3.Client {
+	endpoint := os.Getenv("OUTBOX_TEST_S3_ENDPOINT")
+	if endpoint == "" {
+		endpoint = "http://localhost:9000"
+	}
+
+	client := s3.New(s3.Options{
+		Region:       "us-east-1",
+		BaseEndpoint: aws.String(endpoint),
+		UsePathStyle: true,
+		Credentials: aws.CredentialsProviderFunc(func(ctx context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "minioadmin", SecretAccessKey: "minioadmin"}, nil
+		}),
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if _, err := client.ListBuckets(ctx, &s3.ListBucketsInput{}); err != nil {
+		t.Skipf("Skipping S3 integration test, object store is unavailable: %v", err)
+	}
+
+	return client
+}
+
+func TestSQSPublisher_Publish_OffloadsLargePayloadToS3(t *testing.T) {
+	s3Client := newTestS3Client(t)
+	ctx := context.Background()
+
+	bucket := "outbox-payloads"
+	_, err := s3Client.CreateBucket(ctx, &s3.CreateBucketInput{Bucket: aws.String(bucket)})
+	var owned *types.BucketAlreadyOwnedByYou
+	if !errors.As(err, &owned) {
+		require.NoError(t, err)
+	}
+
+	mockClient := new(MockSQSClient)
+	publisher := NewSQSOutboxMessagePublisherWithClients(mockClient, s3Client, "https://sqs.example.com/queue", DefaultClaimCheckConfigs(bucket))
+
+	var sentBody string
+	mockClient.On("SendMessage", mock.Anything, mock.MatchedBy(func(input *sqs.SendMessageInput) bool {
+		sentBody = *input.MessageBody
+		return true
+	})).Return(&sqs.SendMessageOutput{MessageId: aws.String("msg-123")}, nil)
+
+	largePayload := strings.Repeat("x", MaxMessageSize+1)
+	testMessage := core.OutboxMessage{
+		ID:      "integration-" + time.Now().Format("20060102150405.000000000"),
+		Payload: largePayload,
+		Status:  core.MessageStatusPending,
+	}
+
+	err = publisher.Publish(ctx, testMessage)
+	require.NoError(t, err)
+	mockClient.AssertExpectations(t)
+
+	pointer, ok := ParsePayloadS3Pointer(sentBody)
+	require.True(t, ok)
+	assert.Equal(t, PayloadS3Pointer{S3BucketName: bucket, S3Key: testMessage.ID}, pointer)
+
+	payload, err := ResolvePayload(ctx, s3Client, sentBody)
+	require.NoError(t, err)
+	assert.Equal(t, largePayload, payload)
+
+	_, _ = s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: aws.String(bucket), Key: aws.String(testMessage.ID)})
+}
@@ -0,0 +1,146 @@
+package sqs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"go-transactional-outbox/pkg/core"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// FakeS3Client is an in-memory stand-in for an S3-compatible object store.
+type FakeS3Client struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	putErr  error
+}
+
+func NewFakeS3Client() *FakeS3Client {
+	return &FakeS3Client{objects: make(map[string][]byte)}
+}
+
+func (f *FakeS3Client) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	if f.putErr != nil {
+		return nil, f.putErr
+	}
+
+	body, err := io.ReadAll(params.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.objects[*params.Bucket+"/"+*params.Key] = body
+
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (f *FakeS3Client) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	body, ok := f.objects[*params.Bucket+"/"+*params.Key]
+	if !ok {
+		return nil, errors.New("NoSuchKey")
+	}
+
+	return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(body))}, nil
+}
+
+func TestSQSPublisher_Publish_OffloadsLargePayload(t *testing.T) {
+	mockClient := new(MockSQSClient)
+	s3Client := NewFakeS3Client()
+	publisher := NewSQSOutboxMessagePublisherWithClients(mockClient, s3Client, "https://sqs.example.com/queue", ClaimCheckConfigs{Bucket: "outbox-payloads", KeyPrefix: "outbox/", Threshold: 1024})
+
+	largePayload := strings.Repeat("x", 2048)
+	testMessage := core.OutboxMessage{
+		ID:      "123",
+		Payload: largePayload,
+		Status:  core.MessageStatusPending,
+	}
+
+	var sentBody string
+	mockClient.On("SendMessage", mock.Anything, mock.MatchedBy(func(input *sqs.SendMessageInput) bool {
+		sentBody = *input.MessageBody
+		return *input.MessageAttributes[ExtendedPayloadSizeAttribute].StringValue == "2048" &&
+			*input.MessageAttributes["MessageID"].StringValue == "123"
+	})).Return(&sqs.SendMessageOutput{MessageId: aws.String("msg-123")}, nil)
+
+	err := publisher.Publish(context.Background(), testMessage)
+	require.NoError(t, err)
+	mockClient.AssertExpectations(t)
+
+	assert.JSONEq(t, `["software.amazon.payloadoffloading.PayloadS3Pointer",{"s3BucketName":"outbox-payloads","s3Key":"outbox/123"}]`, sentBody)
+
+	payload, err := ResolvePayload(context.Background(), s3Client, sentBody)
+	require.NoError(t, err)
+	assert.Equal(t, largePayload, payload)
+}
+
+func TestSQSPublisher_Publish_KeepsSmallPayloadInline(t *testing.T) {
+	mockClient := new(MockSQSClient)
+	s3Client := NewFakeS3Client()
+	publisher := NewSQSOutboxMessagePublisherWithClients(mockClient, s3Client, "https://sqs.example.com/queue", DefaultClaimCheckConfigs("outbox-payloads"))
+
+	testMessage := core.OutboxMessage{ID: "123", Payload: "Test Payload", Status: core.MessageStatusPending}
+
+	mockClient.On("SendMessage", mock.Anything, mock.MatchedBy(func(input *sqs.SendMessageInput) bool {
+		_, offloaded := input.MessageAttributes[ExtendedPayloadSizeAttribute]
+		return *input.MessageBody == "Test Payload" && !offloaded
+	})).Return(&sqs.SendMessageOutput{MessageId: aws.String("msg-123")}, nil)
+
+	err := publisher.Publish(context.Background(), testMessage)
+
+	assert.NoError(t, err)
+	assert.Empty(t, s3Client.objects)
+	mockClient.AssertExpectations(t)
+}
+
+func TestSQSPublisher_Publish_UploadFailure(t *testing.T) {
+	mockClient := new(MockSQSClient)
+	s3Client := NewFakeS3Client()
+	s3Client.putErr = errors.New("access denied")
+	publisher := NewSQSOutboxMessagePublisherWithClients(mockClient, s3Client, "https://sqs.example.com/queue", ClaimCheckConfigs{Bucket: "outbox-payloads", Threshold: 10})
+
+	testMessage := core.OutboxMessage{ID: "123", Payload: strings.Repeat("x", 100), Status: core.MessageStatusPending}
+
+	err := publisher.Publish(context.Background(), testMessage)
+
+	assert.EqualError(t, err, "failed to upload payload to S3: access denied")
+	mockClient.AssertNotCalled(t, "SendMessage", mock.Anything, mock.Anything)
+}
+
+func TestSQSPublisher_Publish_ZeroThresholdDefaultsToMaxMessageSize(t *testing.T) {
+	mockClient := new(MockSQSClient)
+	s3Client := NewFakeS3Client()
+	publisher := NewSQSOutboxMessagePublisherWithClients(mockClient, s3Client, "https://sqs.example.com/queue", ClaimCheckConfigs{Bucket: "outbox-payloads"})
+
+	mockClient.On("SendMessage", mock.Anything, mock.MatchedBy(func(input *sqs.SendMessageInput) bool {
+		return *input.MessageBody == "Test Payload"
+	})).Return(&sqs.SendMessageOutput{MessageId: aws.String("msg-123")}, nil)
+
+	err := publisher.Publish(context.Background(), core.OutboxMessage{ID: "123", Payload: "Test Payload", Status: core.MessageStatusPending})
+
+	require.NoError(t, err)
+	assert.Empty(t, s3Client.objects, "A zero threshold must not offload every payload")
+	mockClient.AssertExpectations(t)
+}
+
+func TestResolvePayload_ReturnsPlainBody(t *testing.T) {
+	payload, err := ResolvePayload(context.Background(), NewFakeS3Client(), `{"event":"created"}`)
+
+	assert.NoError(t, err)
+	assert.Equal(t, `{"event":"created"}`, payload)
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
 )
@@ -16,8 +17,9 @@ type SQSClient interface {
 }
 
 type SQSPublisher struct {
-	client   SQSClient
-	queueURL string
+	client     SQSClient
+	queueURL   string
+	claimCheck *claimCheck
 }
 
 func NewSQSOutboxMessagePublisher(ctx context.Context, queueURL string) (*SQSPublisher, error) {
@@ -34,6 +36,35 @@ func NewSQSOutboxMessagePublisher(ctx context.Context, queueURL string) (*SQSPub
 	}, nil
 }
 
+// NewSQSOutboxMessagePublisherWithClaimCheck creates a publisher that offloads payloads
+// exceeding the configured threshold to S3 and sends a pointer to them instead.
+func NewSQSOutboxMessagePublisherWithClaimCheck(ctx context.Context, queueURL string, configs ClaimCheckConfigs) (*SQSPublisher, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+
+	return NewSQSOutboxMessagePublisherWithClients(sqs.NewFromConfig(cfg), s3.NewFromConfig(cfg), queueURL, configs), nil
+}
+
+// NewSQSOutboxMessagePublisherWithClients creates a claim check publisher around the given clients,
+// for example to use a different region, endpoint or credentials for S3 than for SQS. A Threshold
+// of zero or less means MaxMessageSize, so only payloads SQS would reject are offloaded.
+func NewSQSOutboxMessagePublisherWithClients(client SQSClient, s3Client S3Client, queueURL string, configs ClaimCheckConfigs) *SQSPublisher {
+	if configs.Threshold <= 0 {
+		configs.Threshold = MaxMessageSize
+	}
+
+	return &SQSPublisher{
+		client:   client,
+		queueURL: queueURL,
+		claimCheck: &claimCheck{
+			client:  s3Client,
+			configs: configs,
+		},
+	}
+}
+
 func (p *SQSPublisher) Publish(ctx context.Context, message core.OutboxMessage) error {
 	input := &sqs.SendMessageInput{
 		QueueUrl:    aws.String(p.queueURL),
@@ -46,6 +77,12 @@ func (p *SQSPublisher) Publish(ctx context.Context, message core.OutboxMessage)
 		},
 	}
 
+	if p.claimCheck != nil && p.claimCheck.shouldOffload(input) {
+		if err := p.claimCheck.offload(ctx, message.ID, input); err != nil {
+			return err
+		}
+	}
+
 	_, err := p.client.SendMessage(ctx, input)
 	if err != nil {
 		return fmt.Errorf("failed to send message to SQS: %w", err)
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"go-transactional-outbox/pkg/core"
+	"go-transactional-outbox/pkg/publisher/publishertest"
+	"sync"
 	"testing"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -79,3 +81,56 @@ func TestSQSPublisher_Publish_Failure(t *testing.T) {
 	assert.EqualError(t, err, fmt.Sprintf("failed to send message to SQS: %s", fakeAwsSqsErrorMessage))
 	mockClient.AssertExpectations(t)
 }
+
+// FakeSQSClient is an in-memory queue for the conformance suite. Like the SDK, it fails
+// requests whose context is done before sending them.
+type FakeSQSClient struct {
+	mu       sync.Mutex
+	received []publishertest.Received
+	failNext error
+}
+
+func (f *FakeSQSClient) SendMessage(ctx context.Context, params *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.failNext; err != nil {
+		f.failNext = nil
+		return nil, err
+	}
+
+	headers := make(map[string]string, len(params.MessageAttributes))
+	for name, attribute := range params.MessageAttributes {
+		headers[name] = aws.ToString(attribute.StringValue)
+	}
+
+	f.received = append(f.received, publishertest.Received{Payload: aws.ToString(params.MessageBody), Headers: headers})
+
+	return &sqs.SendMessageOutput{MessageId: aws.String(fmt.Sprint(len(f.received)))}, nil
+}
+
+func TestConformance(t *testing.T) {
+	publishertest.Run(t, func(t *testing.T) publishertest.Harness {
+		client := &FakeSQSClient{}
+
+		return publishertest.Harness{
+			Publisher: &SQSPublisher{client: client, queueURL: "https://sqs.example.com/queue"},
+			Received: func() []publishertest.Received {
+				client.mu.Lock()
+				defer client.mu.Unlock()
+				return append([]publishertest.Received(nil), client.received...)
+			},
+			FailNext: func(err error) {
+				client.mu.Lock()
+				defer client.mu.Unlock()
+				client.failNext = err
+			},
+			// SQSPublisher only sends the message ID as a message attribute.
+			SkipHeaders: true,
+		}
+	})
+}
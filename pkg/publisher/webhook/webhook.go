@@ -0,0 +1,167 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"go-transactional-outbox/pkg/core"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HeaderURL is the message header that overrides the publisher's static URL.
+// It is not forwarded as an HTTP header.
+const HeaderURL = "webhook-url"
+
+// HTTP headers defined by the Standard Webhooks specification, plus the idempotency key.
+const (
+	WebhookIDHeader        = "webhook-id"
+	WebhookTimestampHeader = "webhook-timestamp"
+	WebhookSignatureHeader = "webhook-signature"
+	IdempotencyKeyHeader   = "Idempotency-Key"
+)
+
+const secretPrefix = "whsec_"
+
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+type WebhookConfigs struct {
+	URL     string        // Endpoint used for messages without a webhook-url header.
+	Secrets []string      // Signing secrets. Every secret adds a signature, so receivers can rotate keys without downtime.
+	Timeout time.Duration // Maximum duration of a single delivery.
+}
+
+func DefaultWebhookConfigs(url string, secrets ...string) WebhookConfigs {
+	return WebhookConfigs{
+		URL:     url,
+		Secrets: secrets,
+		Timeout: 10 * time.Second,
+	}
+}
+
+type WebhookPublisher struct {
+	client  HTTPClient
+	configs WebhookConfigs
+	now     func() time.Time
+}
+
+// NewWebhookOutboxMessagePublisher creates a publisher whose client does not follow redirects:
+// a 301 or 302 would turn the POST into a GET, whose 2xx would be taken for a delivery.
+func NewWebhookOutboxMessagePublisher(configs WebhookConfigs) *WebhookPublisher {
+	return &WebhookPublisher{
+		client: &http.Client{
+			Timeout: configs.Timeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		configs: configs,
+		now:     time.Now,
+	}
+}
+
+// Publish POSTs the payload and maps the response status to the dispatcher's error semantics:
+// 2xx succeeds, 408, 429 and 5xx are retried (honouring Retry-After), and any other status,
+// including a redirect, is a permanent failure.
+func (p *WebhookPublisher) Publish(ctx context.Context, message core.OutboxMessage) error {
+	url := p.configs.URL
+	if headerURL := message.Headers[HeaderURL]; headerURL != "" {
+		url = headerURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBufferString(message.Payload))
+	if err != nil {
+		return core.NewPermanentError(fmt.Errorf("failed to create webhook request: %w", err))
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	for name, value := range message.Headers {
+		if name != HeaderURL {
+			req.Header.Set(name, value)
+		}
+	}
+
+	timestamp := p.now().Unix()
+
+	req.Header.Set(IdempotencyKeyHeader, message.ID)
+	req.Header.Set(WebhookIDHeader, message.ID)
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+
+	if len(p.configs.Secrets) > 0 {
+		signatures := make([]string, 0, len(p.configs.Secrets))
+		for _, secret := range p.configs.Secrets {
+			signatures = append(signatures, Sign(secret, message.ID, timestamp, message.Payload))
+		}
+		req.Header.Set(WebhookSignatureHeader, strings.Join(signatures, " "))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to deliver webhook: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	// Drain the body so the connection can be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusRequestTimeout,
+		resp.StatusCode == http.StatusTooManyRequests,
+		resp.StatusCode >= 500:
+		err := fmt.Errorf("webhook endpoint responded with status %d", resp.StatusCode)
+		if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"), p.now()); ok {
+			return core.NewRetryAfterError(err, retryAfter)
+		}
+		return err
+	default:
+		return core.NewPermanentError(fmt.Errorf("webhook endpoint rejected message with status %d", resp.StatusCode))
+	}
+}
+
+// Sign returns the Standard Webhooks signature ("v1,<base64>") of a payload. Secrets with the
+// "whsec_" prefix are base64 decoded; other secrets are used as they are.
+func Sign(secret string, id string, timestamp int64, payload string) string {
+	key := []byte(secret)
+	if strings.HasPrefix(secret, secretPrefix) {
+		if decoded, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(secret, secretPrefix)); err == nil {
+			key = decoded
+		}
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(id + "." + strconv.FormatInt(timestamp, 10) + "." + payload))
+
+	return "v1," + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := date.Sub(now); delay > 0 {
+			return delay, true
+		}
+		return 0, true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,240 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"go-transactional-outbox/pkg/core"
+	"go-transactional-outbox/pkg/publisher/publishertest"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testNow = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestPublisher(configs WebhookConfigs) *WebhookPublisher {
+	publisher := NewWebhookOutboxMessagePublisher(configs)
+	publisher.now = func() time.Time { return testNow }
+
+	return publisher
+}
+
+func TestWebhookPublisher_Publish_Success(t *testing.T) {
+	var received *http.Request
+	var body string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, _ := io.ReadAll(r.Body)
+		received, body = r, string(payload)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	publisher := newTestPublisher(DefaultWebhookConfigs(server.URL, "whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw", "old-secret"))
+
+	testMessage := core.OutboxMessage{
+		ID:      "msg_123",
+		Payload: `{"event":"order.created"}`,
+		Headers: map[string]string{"X-Event-Type": "order.created"},
+		Status:  core.MessageStatusPending,
+	}
+
+	err := publisher.Publish(context.Background(), testMessage)
+	require.NoError(t, err)
+
+	timestamp := testNow.Unix()
+	expectedSignatures := Sign("whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw", "msg_123", timestamp, testMessage.Payload) +
+		" " + Sign("old-secret", "msg_123", timestamp, testMessage.Payload)
+
+	assert.Equal(t, http.MethodPost, received.Method)
+	assert.Equal(t, testMessage.Payload, body)
+	assert.Equal(t, "msg_123", received.Header.Get(IdempotencyKeyHeader))
+	assert.Equal(t, "msg_123", received.Header.Get(WebhookIDHeader))
+	assert.Equal(t, "1735732800", received.Header.Get(WebhookTimestampHeader))
+	assert.Equal(t, expectedSignatures, received.Header.Get(WebhookSignatureHeader))
+	assert.Equal(t, "order.created", received.Header.Get("X-Event-Type"))
+	assert.Equal(t, "application/json", received.Header.Get("Content-Type"))
+}
+
+func TestSign_MatchesStandardWebhooksReference(t *testing.T) {
+	// Reference values from the Standard Webhooks specification test vectors.
+	signature := Sign(
+		"whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw",
+		"msg_p5jXN8AQM9LWM0D4loKWxJek",
+		1614265330,
+		`{"test": 2432232314}`,
+	)
+
+	assert.Equal(t, "v1,g0hM9SsE+OTPJTGt/tmIKtSyZlE3uFJELVlNIOLJ1OE=", signature)
+}
+
+func TestWebhookPublisher_Publish_PerMessageURL(t *testing.T) {
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		_, hasURLHeader := r.Header[http.CanonicalHeaderKey(HeaderURL)]
+		assert.False(t, hasURLHeader)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	publisher := newTestPublisher(DefaultWebhookConfigs("http://unused.invalid"))
+
+	err := publisher.Publish(context.Background(), core.OutboxMessage{
+		ID:      "msg_123",
+		Payload: "{}",
+		Headers: map[string]string{HeaderURL: server.URL + "/partners/acme"},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "/partners/acme", path)
+}
+
+func TestWebhookPublisher_Publish_ResponseStatusMapping(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		retryAfter  string
+		permanent   bool
+		expectDelay time.Duration
+		hasDelay    bool
+	}{
+		{name: "bad request is permanent", status: http.StatusBadRequest, permanent: true},
+		{name: "gone is permanent", status: http.StatusGone, permanent: true},
+		{name: "server error is retried", status: http.StatusBadGateway},
+		{name: "too many requests honours seconds", status: http.StatusTooManyRequests, retryAfter: "120", expectDelay: 2 * time.Minute, hasDelay: true},
+		{name: "unavailable honours http date", status: http.StatusServiceUnavailable, retryAfter: testNow.Add(30 * time.Second).Format(http.TimeFormat), expectDelay: 30 * time.Second, hasDelay: true},
+		{name: "request timeout is retried", status: http.StatusRequestTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			publisher := newTestPublisher(DefaultWebhookConfigs(server.URL))
+
+			err := publisher.Publish(context.Background(), core.OutboxMessage{ID: "msg_123", Payload: "{}"})
+			require.Error(t, err)
+
+			assert.Equal(t, tt.permanent, core.IsPermanentError(err))
+
+			delay, ok := core.RetryAfter(err)
+			assert.Equal(t, tt.hasDelay, ok)
+			assert.Equal(t, tt.expectDelay, delay)
+		})
+	}
+}
+
+func TestWebhookPublisher_Publish_RedirectIsPermanent(t *testing.T) {
+	for _, status := range []int{http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			var delivered []string
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/moved" {
+					delivered = append(delivered, r.Method)
+					w.WriteHeader(http.StatusOK)
+					return
+				}
+				http.Redirect(w, r, "/moved", status)
+			}))
+			defer server.Close()
+
+			publisher := newTestPublisher(DefaultWebhookConfigs(server.URL))
+
+			err := publisher.Publish(context.Background(), core.OutboxMessage{ID: "msg_123", Payload: "{}"})
+
+			assert.EqualError(t, err, fmt.Sprintf("webhook endpoint rejected message with status %d", status))
+			assert.True(t, core.IsPermanentError(err))
+			assert.Empty(t, delivered, "Redirects must not be followed")
+		})
+	}
+}
+
+func TestWebhookPublisher_Publish_ConnectionFailureIsRetryable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	publisher := newTestPublisher(DefaultWebhookConfigs(server.URL))
+
+	err := publisher.Publish(context.Background(), core.OutboxMessage{ID: "msg_123", Payload: "{}"})
+
+	require.Error(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "failed to deliver webhook"))
+	assert.False(t, core.IsPermanentError(err))
+}
+
+// failingHTTPClient fails the next request with a scripted transport error.
+type failingHTTPClient struct {
+	client HTTPClient
+	mu     sync.Mutex
+	err    error
+}
+
+func (c *failingHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	err := c.err
+	c.err = nil
+	c.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return c.client.Do(req)
+}
+
+func TestConformance(t *testing.T) {
+	publishertest.Run(t, func(t *testing.T) publishertest.Harness {
+		var mu sync.Mutex
+		var received []publishertest.Received
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			payload, _ := io.ReadAll(r.Body)
+
+			headers := make(map[string]string, len(r.Header))
+			for name := range r.Header {
+				headers[strings.ToLower(name)] = r.Header.Get(name)
+			}
+
+			mu.Lock()
+			received = append(received, publishertest.Received{Payload: string(payload), Headers: headers})
+			mu.Unlock()
+
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		t.Cleanup(server.Close)
+
+		client := &failingHTTPClient{client: server.Client()}
+
+		publisher := newTestPublisher(DefaultWebhookConfigs(server.URL))
+		publisher.client = client
+
+		return publishertest.Harness{
+			Publisher: publisher,
+			Received: func() []publishertest.Received {
+				mu.Lock()
+				defer mu.Unlock()
+				return append([]publishertest.Received(nil), received...)
+			},
+			FailNext: func(err error) {
+				client.mu.Lock()
+				defer client.mu.Unlock()
+				client.err = err
+			},
+		}
+	})
+}
@@ -0,0 +1,240 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"go-transactional-outbox/pkg/core"
+	"sort"
+	"sync"
+	"time"
+)
+
+type record struct {
+	message  core.OutboxMessage
+	pickedAt time.Time
+	sequence uint64 // Insertion order, which breaks ties between messages saved at the same instant.
+}
+
+// MemoryRepository keeps the outbox in memory. It follows the semantics of PostgresRepository
+// and is safe for concurrent use, so several dispatchers can share it.
+type MemoryRepository struct {
+	mu         sync.Mutex
+	now        func() time.Time
+	records    map[string]*record
+	deliveries map[string][]string
+	sequence   uint64
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return NewMemoryRepositoryWithClock(time.Now)
+}
+
+// NewMemoryRepositoryWithClock creates a repository that reads the current time from now,
+// which lets tests control availability, retry delays and lock timeouts.
+func NewMemoryRepositoryWithClock(now func() time.Time) *MemoryRepository {
+	return &MemoryRepository{
+		now:        now,
+		records:    make(map[string]*record),
+		deliveries: make(map[string][]string),
+	}
+}
+
+func (r *MemoryRepository) SaveMessage(ctx context.Context, message core.OutboxMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.records[message.ID]; exists {
+		return fmt.Errorf("message %s already exists", message.ID)
+	}
+
+	now := r.now()
+
+	message.Headers = copyHeaders(message.Headers)
+	message.Attempts = 0
+	message.CreatedAt = now
+
+	if message.AvailableAt.IsZero() {
+		message.AvailableAt = now
+	}
+
+	r.sequence++
+	r.records[message.ID] = &record{message: message, sequence: r.sequence}
+
+	return nil
+}
+
+func (r *MemoryRepository) FetchPendingMessages(ctx context.Context, limit uint32, processingLockTimeout uint32) ([]core.OutboxMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	lockExpiry := now.Add(-time.Duration(processingLockTimeout) * time.Second)
+
+	var selected []*record
+
+	for _, rec := range r.records {
+		if rec.message.AvailableAt.After(now) {
+			continue
+		}
+
+		switch rec.message.Status {
+		case core.MessageStatusPending:
+			selected = append(selected, rec)
+		case core.MessageStatusProcessing:
+			// A processing message whose lock expired belongs to a dispatcher that gave up or crashed.
+			if rec.pickedAt.Before(lockExpiry) {
+				selected = append(selected, rec)
+			}
+		}
+	}
+
+	sort.SliceStable(selected, func(i, j int) bool {
+		a, b := selected[i].message, selected[j].message
+		if !a.AvailableAt.Equal(b.AvailableAt) {
+			return a.AvailableAt.Before(b.AvailableAt)
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return selected[i].sequence < selected[j].sequence
+	})
+
+	if uint32(len(selected)) > limit {
+		selected = selected[:limit]
+	}
+
+	messages := make([]core.OutboxMessage, 0, len(selected))
+
+	for _, rec := range selected {
+		rec.message.Status = core.MessageStatusProcessing
+		rec.pickedAt = now
+		messages = append(messages, snapshot(rec))
+	}
+
+	return messages, nil
+}
+
+func (r *MemoryRepository) MarkMessageAsSent(ctx context.Context, id string, shouldIncrementAttempts bool) error {
+	return r.updateMessage(id, shouldIncrementAttempts, func(message *core.OutboxMessage) {
+		message.Status = core.MessageStatusSent
+	})
+}
+
+func (r *MemoryRepository) MarkMessageAsFailed(ctx context.Context, id string, shouldIncrementAttempts bool) error {
+	return r.updateMessage(id, shouldIncrementAttempts, func(message *core.OutboxMessage) {
+		message.Status = core.MessageStatusFailed
+	})
+}
+
+func (r *MemoryRepository) MarkMessageForRetry(ctx context.Context, id string, delay time.Duration, shouldIncrementAttempts bool) error {
+	return r.updateMessage(id, shouldIncrementAttempts, func(message *core.OutboxMessage) {
+		message.Status = core.MessageStatusPending
+		message.AvailableAt = r.now().Add(delay)
+	})
+}
+
+func (r *MemoryRepository) FetchDeliveredDestinations(ctx context.Context, messageID string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]string(nil), r.deliveries[messageID]...), nil
+}
+
+func (r *MemoryRepository) MarkDestinationAsDelivered(ctx context.Context, messageID string, destination string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, delivered := range r.deliveries[messageID] {
+		if delivered == destination {
+			return nil
+		}
+	}
+
+	r.deliveries[messageID] = append(r.deliveries[messageID], destination)
+
+	return nil
+}
+
+func (r *MemoryRepository) RescheduleMessage(ctx context.Context, id string, availableAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rec, ok := r.records[id]
+	if !ok || rec.message.Status != core.MessageStatusPending {
+		return false, nil
+	}
+
+	rec.message.AvailableAt = availableAt
+
+	return true, nil
+}
+
+// CancelMessage deletes a pending message with its delivery records.
+func (r *MemoryRepository) CancelMessage(ctx context.Context, id string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rec, ok := r.records[id]
+	if !ok || rec.message.Status != core.MessageStatusPending {
+		return false, nil
+	}
+
+	delete(r.records, id)
+	delete(r.deliveries, id)
+
+	return true, nil
+}
+
+// Message returns a copy of a stored message, for assertions in tests.
+func (r *MemoryRepository) Message(id string) (core.OutboxMessage, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rec, ok := r.records[id]
+	if !ok {
+		return core.OutboxMessage{}, false
+	}
+
+	return snapshot(rec), true
+}
+
+// updateMessage applies an update to a stored message. Like an UPDATE matching no rows,
+// updating an unknown message is not an error.
+func (r *MemoryRepository) updateMessage(id string, shouldIncrementAttempts bool, update func(message *core.OutboxMessage)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rec, ok := r.records[id]
+	if !ok {
+		return nil
+	}
+
+	update(&rec.message)
+
+	if shouldIncrementAttempts {
+		rec.message.Attempts++
+	}
+
+	return nil
+}
+
+// snapshot copies a stored message so callers cannot modify the repository's state.
+func snapshot(rec *record) core.OutboxMessage {
+	message := rec.message
+	message.Headers = copyHeaders(message.Headers)
+
+	return message
+}
+
+func copyHeaders(headers map[string]string) map[string]string {
+	if headers == nil {
+		return nil
+	}
+
+	copied := make(map[string]string, len(headers))
+	for name, value := range headers {
+		copied[name] = value
+	}
+
+	return copied
+}
@@ -0,0 +1,228 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"go-transactional-outbox/pkg/core"
+	"go-transactional-outbox/pkg/repository/repositorytest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a manually advanced clock.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func setupTest(t *testing.T) (*MemoryRepository, *fakeClock, context.Context) {
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	return NewMemoryRepositoryWithClock(clock.Now), clock, context.Background()
+}
+
+func saveMessages(t *testing.T, repo *MemoryRepository, clock *fakeClock, ids ...string) {
+	for _, id := range ids {
+		err := repo.SaveMessage(context.Background(), core.OutboxMessage{
+			ID:      id,
+			Payload: "Payload " + id,
+			Status:  core.MessageStatusPending,
+		})
+		require.NoError(t, err)
+		clock.Advance(time.Millisecond)
+	}
+}
+
+func TestSaveMessage(t *testing.T) {
+	repo, clock, ctx := setupTest(t)
+
+	message := core.OutboxMessage{
+		ID:      "1",
+		Payload: "Test Payload",
+		Headers: map[string]string{"event-type": "order.created"},
+		Status:  core.MessageStatusPending,
+	}
+
+	err := repo.SaveMessage(ctx, message)
+	require.NoError(t, err)
+
+	saved, ok := repo.Message("1")
+	require.True(t, ok)
+	assert.Equal(t, "Test Payload", saved.Payload)
+	assert.Equal(t, message.Headers, saved.Headers)
+	assert.Equal(t, uint8(0), saved.Attempts)
+	assert.Equal(t, clock.Now(), saved.AvailableAt)
+
+	err = repo.SaveMessage(ctx, message)
+	assert.EqualError(t, err, "message 1 already exists")
+}
+
+func TestFetchPendingMessages(t *testing.T) {
+	repo, clock, ctx := setupTest(t)
+	saveMessages(t, repo, clock, "1", "2", "3")
+
+	messages, err := repo.FetchPendingMessages(ctx, 2, 30)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "1", messages[0].ID)
+	assert.Equal(t, "2", messages[1].ID)
+	assert.Equal(t, core.MessageStatusProcessing, messages[0].Status)
+
+	// Locked messages are not fetched again while the lock holds.
+	messages, err = repo.FetchPendingMessages(ctx, 10, 30)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "3", messages[0].ID)
+}
+
+func TestFetchPendingMessages_OrdersTiesByInsertion(t *testing.T) {
+	repo, _, ctx := setupTest(t)
+
+	// Without advancing the clock every message shares AvailableAt and CreatedAt.
+	var ids []string
+	for i := 0; i < 20; i++ {
+		id := fmt.Sprint(i)
+		ids = append(ids, id)
+		require.NoError(t, repo.SaveMessage(ctx, core.OutboxMessage{ID: id, Payload: "{}", Status: core.MessageStatusPending}))
+	}
+
+	messages, err := repo.FetchPendingMessages(ctx, 100, 30)
+	require.NoError(t, err)
+
+	var fetched []string
+	for _, message := range messages {
+		fetched = append(fetched, message.ID)
+	}
+	assert.Equal(t, ids, fetched)
+}
+
+func TestFetchPendingMessages_ReclaimsExpiredLocks(t *testing.T) {
+	repo, clock, ctx := setupTest(t)
+	saveMessages(t, repo, clock, "1")
+
+	messages, err := repo.FetchPendingMessages(ctx, 10, 30)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+
+	clock.Advance(30 * time.Second)
+	messages, err = repo.FetchPendingMessages(ctx, 10, 30)
+	require.NoError(t, err)
+	assert.Empty(t, messages, "Lock must hold until the timeout has fully elapsed")
+
+	clock.Advance(time.Second)
+	messages, err = repo.FetchPendingMessages(ctx, 10, 30)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "1", messages[0].ID)
+}
+
+func TestMarkMessageForRetry(t *testing.T) {
+	repo, clock, ctx := setupTest(t)
+	saveMessages(t, repo, clock, "1")
+
+	_, err := repo.FetchPendingMessages(ctx, 10, 30)
+	require.NoError(t, err)
+
+	err = repo.MarkMessageForRetry(ctx, "1", 5*time.Second, true)
+	require.NoError(t, err)
+
+	message, _ := repo.Message("1")
+	assert.Equal(t, core.MessageStatusPending, message.Status)
+	assert.Equal(t, uint8(1), message.Attempts)
+
+	clock.Advance(4 * time.Second)
+	messages, err := repo.FetchPendingMessages(ctx, 10, 30)
+	require.NoError(t, err)
+	assert.Empty(t, messages, "Message must not be available before its retry delay")
+
+	clock.Advance(time.Second)
+	messages, err = repo.FetchPendingMessages(ctx, 10, 30)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, uint8(1), messages[0].Attempts)
+}
+
+func TestMarkMessageAsSentAndFailed(t *testing.T) {
+	repo, clock, ctx := setupTest(t)
+	saveMessages(t, repo, clock, "1", "2")
+
+	_, err := repo.FetchPendingMessages(ctx, 10, 30)
+	require.NoError(t, err)
+
+	require.NoError(t, repo.MarkMessageAsSent(ctx, "1", true))
+	require.NoError(t, repo.MarkMessageAsFailed(ctx, "2", false))
+
+	sent, _ := repo.Message("1")
+	assert.Equal(t, core.MessageStatusSent, sent.Status)
+	assert.Equal(t, uint8(1), sent.Attempts)
+
+	failed, _ := repo.Message("2")
+	assert.Equal(t, core.MessageStatusFailed, failed.Status)
+	assert.Equal(t, uint8(0), failed.Attempts)
+
+	// Finished messages are never fetched again, not even after the lock timeout.
+	clock.Advance(time.Hour)
+	messages, err := repo.FetchPendingMessages(ctx, 10, 30)
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+}
+
+func TestFetchPendingMessages_ConcurrentFetchersNeverShareMessages(t *testing.T) {
+	repo, clock, ctx := setupTest(t)
+
+	var ids []string
+	for i := 0; i < 100; i++ {
+		ids = append(ids, fmt.Sprint(i))
+	}
+	saveMessages(t, repo, clock, ids...)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	seen := make(map[string]int)
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				messages, err := repo.FetchPendingMessages(ctx, 3, 30)
+				if err != nil || len(messages) == 0 {
+					return
+				}
+				mu.Lock()
+				for _, message := range messages {
+					seen[message.ID]++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Len(t, seen, 100)
+	for id, count := range seen {
+		assert.Equal(t, 1, count, "Message %s was fetched more than once", id)
+	}
+}
+
+func TestConformance(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) core.OutboxMessageRepository {
+		return NewMemoryRepository()
+	})
+}
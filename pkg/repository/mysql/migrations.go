@@ -0,0 +1,50 @@
+package mysql
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Migrations holds the SQL files that create the outbox tables, for use with external migration tools.
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
+
+// Migrate applies every migration in order. The migrations are idempotent, so it is safe to
+// run on every startup. Statements are executed one by one, as the driver rejects multi-statement
+// queries by default.
+func Migrate(ctx context.Context, db SQLExecutor) error {
+	files, err := Migrations.ReadDir("migrations")
+	if err != nil {
+		return fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		names = append(names, file.Name())
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		content, err := Migrations.ReadFile("migrations/" + name)
+		if err != nil {
+			return fmt.Errorf("failed to read migration %s: %w", name, err)
+		}
+
+		for _, statement := range strings.Split(string(content), ";") {
+			if strings.TrimSpace(statement) == "" {
+				continue
+			}
+
+			if _, err := db.ExecContext(ctx, statement); err != nil {
+				return fmt.Errorf("failed to apply migration %s: %w", name, err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS outbox (
+	id VARCHAR(255) NOT NULL PRIMARY KEY,
+	payload LONGTEXT NOT NULL,
+	headers JSON NULL,
+	ordering_key VARCHAR(255) NOT NULL DEFAULT '',
+	status VARCHAR(50) NOT NULL,
+	attempts TINYINT UNSIGNED NOT NULL DEFAULT 0,
+	available_at DATETIME(6) NOT NULL,
+	created_at DATETIME(6) NOT NULL,
+	picked_at DATETIME(6) NULL,
+	claim_token VARCHAR(64) NULL,
+	INDEX outbox_status_available_at_idx (status, available_at),
+	INDEX outbox_claim_token_idx (claim_token)
+);
+
+CREATE TABLE IF NOT EXISTS outbox_deliveries (
+	message_id VARCHAR(255) NOT NULL,
+	destination VARCHAR(255) NOT NULL,
+	delivered_at DATETIME(6) NOT NULL,
+	PRIMARY KEY (message_id, destination)
+);
@@ -0,0 +1,349 @@
+package mysql
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go-transactional-outbox/pkg/core"
+	"strings"
+	"time"
+)
+
+// SQLExecutor represents shared methods between *sql.DB and *sql.Tx
+type SQLExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// txBeginner is implemented by *sql.DB. When the executor is already a transaction,
+// statements that need one run in it directly.
+type txBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// ClaimStrategy selects how FetchPendingMessages locks messages for a dispatcher.
+type ClaimStrategy string
+
+const (
+	// ClaimStrategySkipLocked selects rows with SELECT ... FOR UPDATE SKIP LOCKED (MySQL 8, MariaDB 10.6+).
+	ClaimStrategySkipLocked ClaimStrategy = "skip_locked"
+	// ClaimStrategyClaimToken stamps rows with a random token in a single UPDATE and reads them back
+	// by token. It works on servers without SKIP LOCKED, at the cost of dispatchers waiting on each other's row locks.
+	ClaimStrategyClaimToken ClaimStrategy = "claim_token"
+)
+
+type MySQLRepository struct {
+	db            SQLExecutor
+	claimStrategy ClaimStrategy
+}
+
+func NewMySQLRepository(db SQLExecutor, claimStrategy ClaimStrategy) *MySQLRepository {
+	return &MySQLRepository{
+		db:            db,
+		claimStrategy: claimStrategy,
+	}
+}
+
+func (r *MySQLRepository) SaveMessage(ctx context.Context, message core.OutboxMessage) error {
+	headers, err := encodeHeaders(message.Headers)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.ExecContext(ctx,
+		"INSERT INTO outbox (id, payload, headers, ordering_key, status, attempts, available_at, created_at) VALUES (?, ?, ?, ?, ?, 0, NOW(6) + INTERVAL ? MICROSECOND, NOW(6))",
+		message.ID, message.Payload, headers, message.OrderingKey, message.Status, scheduleDelay(message.AvailableAt).Microseconds())
+	return err
+}
+
+func (r *MySQLRepository) FetchPendingMessages(ctx context.Context, limit uint32, processingLockTimeout uint32) ([]core.OutboxMessage, error) {
+	if r.claimStrategy == ClaimStrategyClaimToken {
+		return r.fetchWithClaimToken(ctx, limit, processingLockTimeout)
+	}
+
+	var messages []core.OutboxMessage
+
+	err := r.withTx(ctx, func(db SQLExecutor) error {
+		rows, err := db.QueryContext(ctx, `
+			SELECT id
+			FROM outbox
+			WHERE available_at <= NOW(6)
+				AND (status = ? OR (status = ? AND picked_at < NOW(6) - INTERVAL ? SECOND))
+			ORDER BY available_at ASC, created_at ASC
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED`,
+			core.MessageStatusPending,
+			core.MessageStatusProcessing,
+			processingLockTimeout,
+			limit,
+		)
+		if err != nil {
+			return err
+		}
+
+		var ids []interface{}
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return err
+			}
+			ids = append(ids, id)
+		}
+		rows.Close()
+
+		if err := rows.Err(); err != nil || len(ids) == 0 {
+			return err
+		}
+
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+
+		_, err = db.ExecContext(ctx,
+			"UPDATE outbox SET status = ?, picked_at = NOW(6) WHERE id IN ("+placeholders+")",
+			append([]interface{}{core.MessageStatusProcessing}, ids...)...,
+		)
+		if err != nil {
+			return err
+		}
+
+		messages, err = r.queryMessages(ctx, db, "id IN ("+placeholders+")", ids...)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+func (r *MySQLRepository) fetchWithClaimToken(ctx context.Context, limit uint32, processingLockTimeout uint32) ([]core.OutboxMessage, error) {
+	token, err := newClaimToken()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		UPDATE outbox
+		SET status = ?, picked_at = NOW(6), claim_token = ?
+		WHERE available_at <= NOW(6)
+			AND (status = ? OR (status = ? AND picked_at < NOW(6) - INTERVAL ? SECOND))
+		ORDER BY available_at ASC, created_at ASC
+		LIMIT ?`,
+		core.MessageStatusProcessing,
+		token,
+		core.MessageStatusPending,
+		core.MessageStatusProcessing,
+		processingLockTimeout,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.queryMessages(ctx, r.db, "claim_token = ?", token)
+}
+
+func (r *MySQLRepository) MarkMessageAsSent(ctx context.Context, id string, shouldIncrementAttempts bool) error {
+	return r.updateMessageStatus(ctx, id, core.MessageStatusSent, shouldIncrementAttempts)
+}
+
+func (r *MySQLRepository) MarkMessageAsFailed(ctx context.Context, id string, shouldIncrementAttempts bool) error {
+	return r.updateMessageStatus(ctx, id, core.MessageStatusFailed, shouldIncrementAttempts)
+}
+
+func (r *MySQLRepository) MarkMessageForRetry(ctx context.Context, id string, delay time.Duration, shouldIncrementAttempts bool) error {
+	query := "UPDATE outbox SET status = ?"
+
+	if shouldIncrementAttempts {
+		query += ", attempts = attempts + 1"
+	}
+
+	query += ", available_at = NOW(6) + INTERVAL ? MICROSECOND"
+
+	query += " WHERE id = ?"
+
+	_, err := r.db.ExecContext(ctx, query, core.MessageStatusPending, delay.Microseconds(), id)
+
+	return err
+}
+
+func (r *MySQLRepository) RescheduleMessage(ctx context.Context, id string, availableAt time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE outbox SET available_at = NOW(6) + INTERVAL ? MICROSECOND WHERE id = ? AND status = ?",
+		scheduleDelay(availableAt).Microseconds(), id, core.MessageStatusPending)
+	if err != nil {
+		return false, err
+	}
+
+	return isAffected(result)
+}
+
+// CancelMessage deletes a pending message with its delivery records.
+func (r *MySQLRepository) CancelMessage(ctx context.Context, id string) (bool, error) {
+	canceled := false
+
+	err := r.withTx(ctx, func(db SQLExecutor) error {
+		result, err := db.ExecContext(ctx, "DELETE FROM outbox WHERE id = ? AND status = ?", id, core.MessageStatusPending)
+		if err != nil {
+			return err
+		}
+
+		if canceled, err = isAffected(result); err != nil || !canceled {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, "DELETE FROM outbox_deliveries WHERE message_id = ?", id)
+		return err
+	})
+
+	return canceled, err
+}
+
+func (r *MySQLRepository) FetchDeliveredDestinations(ctx context.Context, messageID string) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT destination FROM outbox_deliveries WHERE message_id = ?", messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var destinations []string
+
+	for rows.Next() {
+		var destination string
+		if err := rows.Scan(&destination); err != nil {
+			return nil, err
+		}
+		destinations = append(destinations, destination)
+	}
+
+	return destinations, rows.Err()
+}
+
+func (r *MySQLRepository) MarkDestinationAsDelivered(ctx context.Context, messageID string, destination string) error {
+	_, err := r.db.ExecContext(ctx,
+		"INSERT IGNORE INTO outbox_deliveries (message_id, destination, delivered_at) VALUES (?, ?, NOW(6))",
+		messageID, destination)
+	return err
+}
+
+func (r *MySQLRepository) updateMessageStatus(ctx context.Context, id string, status core.MessageStatus, shouldIncrementAttempts bool) error {
+	query := "UPDATE outbox SET status = ?"
+
+	if shouldIncrementAttempts {
+		query += ", attempts = attempts + 1"
+	}
+
+	query += " WHERE id = ?"
+
+	_, err := r.db.ExecContext(ctx, query, status, id)
+
+	return err
+}
+
+func (r *MySQLRepository) queryMessages(ctx context.Context, db SQLExecutor, condition string, args ...interface{}) ([]core.OutboxMessage, error) {
+	rows, err := db.QueryContext(ctx,
+		"SELECT id, payload, headers, ordering_key, status, attempts FROM outbox WHERE "+condition+" ORDER BY available_at ASC, created_at ASC",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var messages []core.OutboxMessage
+
+	for rows.Next() {
+		var message core.OutboxMessage
+		var headers []byte
+		if err := rows.Scan(&message.ID, &message.Payload, &headers, &message.OrderingKey, &message.Status, &message.Attempts); err != nil {
+			return nil, err
+		}
+		if message.Headers, err = decodeHeaders(headers); err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+
+	return messages, rows.Err()
+}
+
+// withTx runs fn in a new transaction, or directly when the executor is already a transaction.
+func (r *MySQLRepository) withTx(ctx context.Context, fn func(db SQLExecutor) error) error {
+	beginner, ok := r.db.(txBeginner)
+	if !ok {
+		return fn(r.db)
+	}
+
+	tx, err := beginner.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func newClaimToken() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", fmt.Errorf("failed to generate claim token: %w", err)
+	}
+
+	return hex.EncodeToString(token), nil
+}
+
+// encodeHeaders converts message headers to the JSON stored in the headers column.
+func encodeHeaders(headers map[string]string) ([]byte, error) {
+	if len(headers) == 0 {
+		return nil, nil
+	}
+
+	encoded, err := json.Marshal(headers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode message headers: %w", err)
+	}
+
+	return encoded, nil
+}
+
+func decodeHeaders(encoded []byte) (map[string]string, error) {
+	if len(encoded) == 0 {
+		return nil, nil
+	}
+
+	var headers map[string]string
+	if err := json.Unmarshal(encoded, &headers); err != nil {
+		return nil, fmt.Errorf("failed to decode message headers: %w", err)
+	}
+
+	return headers, nil
+}
+
+// scheduleDelay converts the time of a scheduled message to a delay from now, so availability is
+// computed by the database's clock like that of retries.
+func scheduleDelay(availableAt time.Time) time.Duration {
+	if availableAt.IsZero() {
+		return 0
+	}
+
+	return time.Until(availableAt)
+}
+
+func isAffected(result sql.Result) (bool, error) {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
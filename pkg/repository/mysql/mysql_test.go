@@ -0,0 +1,301 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go-transactional-outbox/pkg/core"
+	"go-transactional-outbox/pkg/repository/repositorytest"
+	"log"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testDB *sql.DB
+
+func TestMain(m *testing.M) {
+	setupDatabase()
+
+	// Run tests
+	code := m.Run()
+
+	teardownDatabase()
+
+	os.Exit(code)
+}
+
+func setupDatabase() {
+	dsn := os.Getenv("OUTBOX_TEST_MYSQL_DSN")
+	if dsn == "" {
+		dsn = "root:secret@tcp(localhost:3306)/testdb?parseTime=true"
+	}
+
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		log.Fatalf("Failed to open test database: %v", err)
+	}
+
+	// Tests are skipped when no MySQL server is running (see docker-compose.yml).
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		log.Printf("Skipping MySQL tests, test database is unavailable: %v", err)
+		_ = db.Close()
+		return
+	}
+
+	if err := Migrate(context.Background(), db); err != nil {
+		log.Fatalf("Failed to migrate test database: %v", err)
+	}
+
+	testDB = db
+}
+
+func teardownDatabase() {
+	if testDB == nil {
+		return
+	}
+
+	_, _ = testDB.Exec(`DROP TABLE IF EXISTS outbox_deliveries`)
+	_, _ = testDB.Exec(`DROP TABLE IF EXISTS outbox`)
+
+	testDB.Close()
+}
+
+func setupTest(t *testing.T) (*sql.Tx, context.Context) {
+	if testDB == nil {
+		t.Skip("MySQL test database is unavailable")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel) // Ensure the timeout context is canceled after the test
+
+	// Begin a database transaction before each test.
+	tx, err := testDB.BeginTx(ctx, nil)
+	require.NoError(t, err, "Failed to start transaction")
+
+	// Rollback the database transaction after the test.
+	t.Cleanup(func() {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			t.Errorf("Failed to rollback transaction: %v", err)
+		}
+	})
+
+	return tx, ctx
+}
+
+func TestSaveMessage(t *testing.T) {
+	tx, ctx := setupTest(t)
+
+	repo := NewMySQLRepository(tx, ClaimStrategySkipLocked)
+
+	message := core.OutboxMessage{
+		ID:      "1",
+		Payload: "Test Payload",
+		Headers: map[string]string{"event-type": "order.created"},
+		Status:  core.MessageStatusPending,
+	}
+
+	err := repo.SaveMessage(ctx, message)
+	require.NoError(t, err, "Failed to save message")
+
+	var count int
+	err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM outbox WHERE id = ?`, message.ID).Scan(&count)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count, "Message was not saved correctly")
+}
+
+func TestFetchPendingMessages(t *testing.T) {
+	for _, strategy := range []ClaimStrategy{ClaimStrategySkipLocked, ClaimStrategyClaimToken} {
+		t.Run(string(strategy), func(t *testing.T) {
+			tx, ctx := setupTest(t)
+
+			repo := NewMySQLRepository(tx, strategy)
+
+			for i, payload := range []string{"Payload 1", "Payload 2"} {
+				err := repo.SaveMessage(ctx, core.OutboxMessage{
+					ID:          fmt.Sprint(i + 2),
+					Payload:     payload,
+					Headers:     map[string]string{"index": fmt.Sprint(i)},
+					OrderingKey: "order-42",
+					Status:      core.MessageStatusPending,
+				})
+				require.NoError(t, err)
+			}
+
+			messages, err := repo.FetchPendingMessages(ctx, 10, 30)
+			require.NoError(t, err)
+			require.Len(t, messages, 2, "Expected 2 pending messages")
+
+			assert.Equal(t, "Payload 1", messages[0].Payload)
+			assert.Equal(t, "Payload 2", messages[1].Payload)
+			assert.Equal(t, map[string]string{"index": "0"}, messages[0].Headers)
+			assert.Equal(t, "order-42", messages[0].OrderingKey)
+			assert.Equal(t, core.MessageStatusProcessing, messages[0].Status)
+
+			messages, err = repo.FetchPendingMessages(ctx, 10, 30)
+			require.NoError(t, err)
+			assert.Empty(t, messages, "Locked messages must not be fetched again")
+		})
+	}
+}
+
+func TestMarkMessageAsSent(t *testing.T) {
+	tx, ctx := setupTest(t)
+
+	repo := NewMySQLRepository(tx, ClaimStrategySkipLocked)
+
+	require.NoError(t, repo.SaveMessage(ctx, core.OutboxMessage{ID: "4", Payload: "Payload Sent", Status: core.MessageStatusPending}))
+
+	// Mark message as sent
+	err := repo.MarkMessageAsSent(ctx, "4", true)
+	require.NoError(t, err)
+
+	// Verify in database
+	var status core.MessageStatus
+	var attempts uint8
+	err = tx.QueryRowContext(ctx, `SELECT status, attempts FROM outbox WHERE id = ?`, "4").Scan(&status, &attempts)
+	require.NoError(t, err)
+	assert.Equal(t, core.MessageStatusSent, status, "Message status was not updated to sent")
+	assert.Equal(t, uint8(1), attempts)
+}
+
+func TestMarkMessageAsFailed(t *testing.T) {
+	tx, ctx := setupTest(t)
+
+	repo := NewMySQLRepository(tx, ClaimStrategySkipLocked)
+
+	require.NoError(t, repo.SaveMessage(ctx, core.OutboxMessage{ID: "5", Payload: "Payload Failed", Status: core.MessageStatusPending}))
+
+	// Mark message as failed
+	err := repo.MarkMessageAsFailed(ctx, "5", false)
+	require.NoError(t, err)
+
+	// Verify in database
+	var status core.MessageStatus
+	var attempts uint8
+	err = tx.QueryRowContext(ctx, `SELECT status, attempts FROM outbox WHERE id = ?`, "5").Scan(&status, &attempts)
+	require.NoError(t, err)
+	assert.Equal(t, core.MessageStatusFailed, status, "Message status was not updated to failed")
+	assert.Equal(t, uint8(0), attempts)
+}
+
+func TestMarkMessageForRetry(t *testing.T) {
+	tx, ctx := setupTest(t)
+
+	repo := NewMySQLRepository(tx, ClaimStrategySkipLocked)
+
+	require.NoError(t, repo.SaveMessage(ctx, core.OutboxMessage{ID: "6", Payload: "Payload Retry", Status: core.MessageStatusPending}))
+
+	_, err := repo.FetchPendingMessages(ctx, 10, 30)
+	require.NoError(t, err)
+
+	err = repo.MarkMessageForRetry(ctx, "6", time.Hour, true)
+	require.NoError(t, err)
+
+	messages, err := repo.FetchPendingMessages(ctx, 10, 30)
+	require.NoError(t, err)
+	assert.Empty(t, messages, "Message must not be available before its retry delay")
+
+	err = repo.MarkMessageForRetry(ctx, "6", 0, true)
+	require.NoError(t, err)
+
+	messages, err = repo.FetchPendingMessages(ctx, 10, 30)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, uint8(2), messages[0].Attempts)
+}
+
+func TestMarkDestinationAsDelivered(t *testing.T) {
+	tx, ctx := setupTest(t)
+
+	repo := NewMySQLRepository(tx, ClaimStrategySkipLocked)
+
+	require.NoError(t, repo.MarkDestinationAsDelivered(ctx, "7", "sqs"))
+	require.NoError(t, repo.MarkDestinationAsDelivered(ctx, "7", "kafka"))
+	require.NoError(t, repo.MarkDestinationAsDelivered(ctx, "7", "sqs"))
+
+	destinations, err := repo.FetchDeliveredDestinations(ctx, "7")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"sqs", "kafka"}, destinations)
+}
+
+// TestFetchPendingMessages_ConcurrentFetchers runs against committed rows, as concurrent
+// dispatchers only contend outside of a shared transaction.
+func TestFetchPendingMessages_ConcurrentFetchers(t *testing.T) {
+	for _, strategy := range []ClaimStrategy{ClaimStrategySkipLocked, ClaimStrategyClaimToken} {
+		t.Run(string(strategy), func(t *testing.T) {
+			if testDB == nil {
+				t.Skip("MySQL test database is unavailable")
+			}
+
+			ctx := context.Background()
+			repo := NewMySQLRepository(testDB, strategy)
+
+			_, err := testDB.ExecContext(ctx, `DELETE FROM outbox`)
+			require.NoError(t, err)
+			t.Cleanup(func() { _, _ = testDB.ExecContext(ctx, `DELETE FROM outbox`) })
+
+			for i := 0; i < 50; i++ {
+				require.NoError(t, repo.SaveMessage(ctx, core.OutboxMessage{ID: fmt.Sprint(i), Payload: "Payload", Status: core.MessageStatusPending}))
+			}
+
+			var mu sync.Mutex
+			var wg sync.WaitGroup
+			seen := make(map[string]int)
+
+			for i := 0; i < 5; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for {
+						messages, err := repo.FetchPendingMessages(ctx, 4, 30)
+						if !assert.NoError(t, err) || len(messages) == 0 {
+							return
+						}
+						mu.Lock()
+						for _, message := range messages {
+							seen[message.ID]++
+						}
+						mu.Unlock()
+					}
+				}()
+			}
+
+			wg.Wait()
+
+			assert.Len(t, seen, 50)
+			for id, count := range seen {
+				assert.Equal(t, 1, count, "Message %s was fetched more than once", id)
+			}
+		})
+	}
+}
+
+// TestConformance runs against committed rows, as the suite relies on the clock advancing
+// between statements and on concurrent dispatchers contending for rows.
+func TestConformance(t *testing.T) {
+	for _, strategy := range []ClaimStrategy{ClaimStrategySkipLocked, ClaimStrategyClaimToken} {
+		t.Run(string(strategy), func(t *testing.T) {
+			repositorytest.Run(t, func(t *testing.T) core.OutboxMessageRepository {
+				if testDB == nil {
+					t.Skip("MySQL test database is unavailable")
+				}
+
+				_, err := testDB.Exec(`DELETE FROM outbox`)
+				require.NoError(t, err)
+				_, err = testDB.Exec(`DELETE FROM outbox_deliveries`)
+				require.NoError(t, err)
+
+				return NewMySQLRepository(testDB, strategy)
+			})
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"go-transactional-outbox/pkg/core"
+	"hash/fnv"
+	"time"
+)
+
+type AdvisoryLockConfigs struct {
+	TableName         string        // Outbox table the lock is keyed by. Dispatchers of the same table elect a single leader.
+	HeartbeatInterval time.Duration // How often the leader checks that its session still holds the lock. Zero uses the default.
+}
+
+func DefaultAdvisoryLockConfigs() AdvisoryLockConfigs {
+	return AdvisoryLockConfigs{
+		TableName:         "outbox",
+		HeartbeatInterval: 5 * time.Second,
+	}
+}
+
+// AdvisoryLockElector is a core.OutboxLeaderElector backed by a session-level advisory lock.
+//
+// The leader holds the lock on a dedicated connection. Postgres releases it when the session
+// ends, so a crashed leader hands over as soon as its connection is gone. The leader checks its
+// session every HeartbeatInterval and steps down when the check fails, which bounds how long it
+// may keep dispatching after its session was lost.
+type AdvisoryLockElector struct {
+	db      *sql.DB
+	configs AdvisoryLockConfigs
+	key     int64
+}
+
+func NewAdvisoryLockElector(db *sql.DB, configs AdvisoryLockConfigs) *AdvisoryLockElector {
+	return &AdvisoryLockElector{
+		db:      db,
+		configs: configs,
+		key:     advisoryLockKey(configs.TableName),
+	}
+}
+
+func (e *AdvisoryLockElector) AcquireLeadership(ctx context.Context) (context.Context, func(), error) {
+	conn, err := e.db.Conn(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open advisory lock connection: %w", err)
+	}
+
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", e.key).Scan(&acquired); err != nil {
+		discardConn(conn)
+		return nil, nil, fmt.Errorf("failed to acquire advisory lock: %w", err)
+	}
+
+	if !acquired {
+		_ = conn.Close()
+		return nil, nil, core.ErrNotLeader
+	}
+
+	leaderCtx, cancel := context.WithCancel(ctx)
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		e.heartbeat(leaderCtx, conn)
+		cancel()
+	}()
+
+	release := func() {
+		cancel()
+		<-stopped
+		e.unlock(conn)
+	}
+
+	return leaderCtx, release, nil
+}
+
+// heartbeat returns once ctx is done or the session no longer holds the lock.
+func (e *AdvisoryLockElector) heartbeat(ctx context.Context, conn *sql.Conn) {
+	ticker := time.NewTicker(e.heartbeatInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !e.holdsLock(ctx, conn) {
+				return
+			}
+		}
+	}
+}
+
+func (e *AdvisoryLockElector) heartbeatInterval() time.Duration {
+	if e.configs.HeartbeatInterval <= 0 {
+		return DefaultAdvisoryLockConfigs().HeartbeatInterval
+	}
+
+	return e.configs.HeartbeatInterval
+}
+
+// holdsLock checks the lock in pg_locks rather than only pinging, so a lock released behind the
+// leader's back, for example by a pooler that reset the session, is noticed too.
+func (e *AdvisoryLockElector) holdsLock(ctx context.Context, conn *sql.Conn) bool {
+	ctx, cancel := context.WithTimeout(ctx, e.heartbeatInterval())
+	defer cancel()
+
+	var held bool
+	err := conn.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1
+			FROM pg_locks
+			WHERE locktype = 'advisory'
+				AND pid = pg_backend_pid()
+				AND granted
+				AND objsubid = 1
+				AND ((classid::bigint << 32) | objid::bigint) = $1
+		)`,
+		e.key,
+	).Scan(&held)
+
+	return err == nil && held
+}
+
+// unlock releases the lock and returns the connection to the pool. A connection whose lock
+// could not be released is closed instead, which makes Postgres release the lock.
+func (e *AdvisoryLockElector) unlock(conn *sql.Conn) {
+	ctx, cancel := context.WithTimeout(context.Background(), e.heartbeatInterval())
+	defer cancel()
+
+	var released bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_advisory_unlock($1)", e.key).Scan(&released); err != nil || !released {
+		discardConn(conn)
+		return
+	}
+
+	_ = conn.Close()
+}
+
+// discardConn closes the underlying connection instead of returning it to the pool.
+func discardConn(conn *sql.Conn) {
+	_ = conn.Raw(func(driverConn any) error {
+		return driver.ErrBadConn
+	})
+	_ = conn.Close()
+}
+
+// advisoryLockKey derives the lock key from the table name with FNV-1a, so every dispatcher
+// of a table computes the same key regardless of its Postgres version.
+func advisoryLockKey(tableName string) int64 {
+	hash := fnv.New64a()
+	hash.Write([]byte("go-transactional-outbox:" + tableName))
+
+	return int64(hash.Sum64())
+}
@@ -0,0 +1,80 @@
+package postgresql
+
+import (
+	"context"
+	"go-transactional-outbox/pkg/core"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdvisoryLockKey(t *testing.T) {
+	assert.Equal(t, advisoryLockKey("outbox"), advisoryLockKey("outbox"))
+	assert.NotEqual(t, advisoryLockKey("outbox"), advisoryLockKey("orders_outbox"))
+}
+
+func TestAdvisoryLockElector_DefaultsHeartbeatInterval(t *testing.T) {
+	elector := NewAdvisoryLockElector(nil, AdvisoryLockConfigs{TableName: "outbox"})
+
+	assert.Equal(t, DefaultAdvisoryLockConfigs().HeartbeatInterval, elector.heartbeatInterval())
+}
+
+func newTestElector(t *testing.T) *AdvisoryLockElector {
+	if testDB == nil {
+		t.Skip("PostgreSQL test database is unavailable")
+	}
+
+	configs := DefaultAdvisoryLockConfigs()
+	configs.TableName = "outbox_" + t.Name()
+	configs.HeartbeatInterval = 50 * time.Millisecond
+
+	return NewAdvisoryLockElector(testDB, configs)
+}
+
+func TestAdvisoryLockElector_SingleLeader(t *testing.T) {
+	leader, standby := newTestElector(t), newTestElector(t)
+	ctx := context.Background()
+
+	leaderCtx, release, err := leader.AcquireLeadership(ctx)
+	require.NoError(t, err)
+
+	_, _, err = standby.AcquireLeadership(ctx)
+	assert.ErrorIs(t, err, core.ErrNotLeader)
+
+	release()
+	assert.Error(t, leaderCtx.Err(), "Leadership context must be canceled once released")
+
+	standbyCtx, release, err := standby.AcquireLeadership(ctx)
+	require.NoError(t, err, "Standby must take over once the leader released the lock")
+	assert.NoError(t, standbyCtx.Err())
+	release()
+}
+
+func TestAdvisoryLockElector_DetectsLostSession(t *testing.T) {
+	leader, standby := newTestElector(t), newTestElector(t)
+	ctx := context.Background()
+
+	leaderCtx, release, err := leader.AcquireLeadership(ctx)
+	require.NoError(t, err)
+	defer release()
+
+	_, err = testDB.ExecContext(ctx, `
+		SELECT pg_terminate_backend(pid)
+		FROM pg_locks
+		WHERE locktype = 'advisory' AND ((classid::bigint << 32) | objid::bigint) = $1`,
+		leader.key,
+	)
+	require.NoError(t, err)
+
+	select {
+	case <-leaderCtx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Leader did not notice its session was lost")
+	}
+
+	_, standbyRelease, err := standby.AcquireLeadership(ctx)
+	require.NoError(t, err, "Standby must take over once the leader's session is gone")
+	standbyRelease()
+}
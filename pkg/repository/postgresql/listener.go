@@ -0,0 +1,90 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// listenerPingInterval is how often an idle listener checks its connection. A connection that
+// dropped silently is otherwise only noticed when the next notification never arrives.
+const listenerPingInterval = 90 * time.Second
+
+// Listener is a core.OutboxMessageNotifier that LISTENs on the NotifyChannel of a
+// PostgresRepository, so the dispatcher run loop dispatches as soon as messages are saved.
+//
+// It holds a dedicated connection that reconnects on its own. Notifications sent while it is
+// disconnected are lost, so it also notifies after reconnecting; until then, the run loop keeps
+// dispatching at its poll interval.
+type Listener struct {
+	listener      *pq.Listener
+	notifications chan struct{}
+}
+
+// NewListener connects to the database and listens on channel. It waits for the connection
+// until ctx is done, so callers can fall back to polling when the database is unreachable.
+func NewListener(ctx context.Context, dsn string, channel string) (*Listener, error) {
+	listener := pq.NewListener(dsn, time.Second, time.Minute, nil)
+
+	listening := make(chan error, 1)
+	go func() { listening <- listener.Listen(channel) }()
+
+	select {
+	case err := <-listening:
+		if err != nil {
+			_ = listener.Close()
+			return nil, fmt.Errorf("failed to listen on channel %s: %w", channel, err)
+		}
+	case <-ctx.Done():
+		// Closing the listener makes the pending Listen call return.
+		_ = listener.Close()
+		return nil, fmt.Errorf("failed to listen on channel %s: %w", channel, ctx.Err())
+	}
+
+	l := &Listener{
+		listener:      listener,
+		notifications: make(chan struct{}, 1),
+	}
+
+	go forwardNotifications(listener.Notify, l.notifications, listener.Ping, listenerPingInterval)
+
+	return l, nil
+}
+
+func (l *Listener) Notifications() <-chan struct{} {
+	return l.notifications
+}
+
+// Close closes the connection. The notifications channel is closed once it has shut down.
+func (l *Listener) Close() error {
+	return l.listener.Close()
+}
+
+// forwardNotifications coalesces notifications into a channel with a buffer of one, as a single
+// wake-up makes the dispatcher fetch every pending message. pq sends a nil notification after a
+// reconnect, which is forwarded like any other.
+func forwardNotifications(notify <-chan *pq.Notification, notifications chan<- struct{}, ping func() error, pingInterval time.Duration) {
+	defer close(notifications)
+
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case _, ok := <-notify:
+			if !ok {
+				return
+			}
+
+			select {
+			case notifications <- struct{}{}:
+			default:
+			}
+		case <-ticker.C:
+			// Pinging a dead connection makes pq notice the loss and reconnect.
+			go func() { _ = ping() }()
+		}
+	}
+}
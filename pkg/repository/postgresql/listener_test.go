@@ -0,0 +1,89 @@
+package postgresql
+
+import (
+	"context"
+	"go-transactional-outbox/pkg/core"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForwardNotifications_CoalescesNotifications(t *testing.T) {
+	notify := make(chan *pq.Notification)
+	notifications := make(chan struct{}, 1)
+
+	go forwardNotifications(notify, notifications, func() error { return nil }, time.Hour)
+
+	notify <- &pq.Notification{Channel: "outbox", Extra: "1"}
+	notify <- &pq.Notification{Channel: "outbox", Extra: "2"}
+	notify <- nil // Sent by pq after a reconnect.
+	close(notify)
+
+	_, ok := <-notifications
+	assert.True(t, ok, "Expected a single coalesced notification")
+
+	_, ok = <-notifications
+	assert.False(t, ok, "Channel must be closed once the listener shut down")
+}
+
+func TestForwardNotifications_PingsIdleConnection(t *testing.T) {
+	notify := make(chan *pq.Notification)
+	notifications := make(chan struct{}, 1)
+
+	var pings atomic.Int32
+	go forwardNotifications(notify, notifications, func() error { pings.Add(1); return nil }, 5*time.Millisecond)
+	t.Cleanup(func() { close(notify) })
+
+	assert.Eventually(t, func() bool { return pings.Load() >= 2 }, time.Second, time.Millisecond)
+}
+
+func TestNewListener_UnreachableDatabase(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	_, err := NewListener(ctx, "host=127.0.0.1 port=1 user=postgres sslmode=disable connect_timeout=1", "outbox")
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestListener_NotifiedOnSaveMessage(t *testing.T) {
+	if testDB == nil {
+		t.Skip("PostgreSQL test database is unavailable")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	listener, err := NewListener(ctx, testDSN, "outbox_test")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	tx, err := testDB.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _, _ = testDB.Exec(`DELETE FROM outbox WHERE id = 'notify-1'`) })
+
+	configs := DefaultPostgresConfigs()
+	configs.NotifyChannel = "outbox_test"
+
+	repo, err := NewPostgresRepositoryWithConfigs(tx, configs)
+	require.NoError(t, err)
+	require.NoError(t, repo.SaveMessage(ctx, core.OutboxMessage{ID: "notify-1", Payload: "Payload", Status: core.MessageStatusPending}))
+
+	select {
+	case <-listener.Notifications():
+		t.Fatal("Notification must not be sent before the transaction commits")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	require.NoError(t, tx.Commit())
+
+	select {
+	case <-listener.Notifications():
+	case <-ctx.Done():
+		t.Fatal("Expected a notification after the transaction committed")
+	}
+}
@@ -0,0 +1,70 @@
+package postgresql
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"sort"
+)
+
+// Migrations holds the SQL files that create the outbox tables, for use with external migration tools.
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
+
+// PartitionedMigrations holds the SQL files that create the outbox partitioned by creation time.
+// They run before Migrations, which then complete the partitioned table.
+//
+//go:embed migrations/partitioned/*.sql
+var PartitionedMigrations embed.FS
+
+// Migrate applies every migration in order. The migrations are idempotent, so it is safe to
+// run on every startup. Each file is executed as a single multi-statement query, which both
+// lib/pq and pgx support when no arguments are passed.
+func Migrate(ctx context.Context, db SQLExecutor) error {
+	return migrate(ctx, db, Migrations, "migrations")
+}
+
+// MigratePartitioned applies the migrations of an outbox partitioned by creation time, for use
+// with a TimePartitioner, and creates the partitions configured by configs. Messages saved before
+// the partitioner first runs therefore never land in the default partition. Message IDs stay
+// unique across partitions through a registry table that triggers on the outbox maintain. An
+// existing unpartitioned outbox is not converted; the partitioned migrations leave it as it is.
+func MigratePartitioned(ctx context.Context, db SQLExecutor, configs TimePartitionConfigs) error {
+	if err := migrate(ctx, db, PartitionedMigrations, "migrations/partitioned"); err != nil {
+		return err
+	}
+
+	if err := Migrate(ctx, db); err != nil {
+		return err
+	}
+
+	return NewTimePartitioner(db, configs).CreatePartitions(ctx)
+}
+
+func migrate(ctx context.Context, db SQLExecutor, migrations embed.FS, dir string) error {
+	files, err := migrations.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		names = append(names, file.Name())
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		content, err := migrations.ReadFile(dir + "/" + name)
+		if err != nil {
+			return fmt.Errorf("failed to read migration %s: %w", name, err)
+		}
+
+		if _, err := db.ExecContext(ctx, string(content)); err != nil {
+			return fmt.Errorf("failed to apply migration %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
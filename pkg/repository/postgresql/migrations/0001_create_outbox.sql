@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS outbox (
+	id VARCHAR(255) NOT NULL PRIMARY KEY,
+	payload TEXT NOT NULL,
+	headers JSONB NULL,
+	ordering_key VARCHAR(255) NOT NULL DEFAULT '',
+	status VARCHAR(50) NOT NULL,
+	attempts SMALLINT NOT NULL DEFAULT 0,
+	available_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	picked_at TIMESTAMPTZ NULL
+);
+
+CREATE INDEX IF NOT EXISTS outbox_status_available_at_idx ON outbox (status, available_at);
+
+CREATE TABLE IF NOT EXISTS outbox_deliveries (
+	message_id VARCHAR(255) NOT NULL,
+	destination VARCHAR(255) NOT NULL,
+	delivered_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (message_id, destination)
+);
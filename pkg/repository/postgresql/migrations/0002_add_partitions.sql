@@ -0,0 +1,14 @@
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS partition_id INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS outbox_partition_status_available_at_idx ON outbox (partition_id, status, available_at);
+
+CREATE TABLE IF NOT EXISTS outbox_dispatchers (
+	instance_id VARCHAR(255) NOT NULL PRIMARY KEY,
+	expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS outbox_partition_leases (
+	partition_id INTEGER NOT NULL PRIMARY KEY,
+	instance_id VARCHAR(255) NOT NULL,
+	expires_at TIMESTAMPTZ NOT NULL
+);
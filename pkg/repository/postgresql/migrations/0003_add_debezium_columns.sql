@@ -0,0 +1,4 @@
+ALTER TABLE outbox
+	ADD COLUMN IF NOT EXISTS aggregatetype VARCHAR(255) NULL,
+	ADD COLUMN IF NOT EXISTS aggregateid VARCHAR(255) NULL,
+	ADD COLUMN IF NOT EXISTS type VARCHAR(255) NULL;
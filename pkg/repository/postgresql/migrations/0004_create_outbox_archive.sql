@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS outbox_archive (
+	id VARCHAR(255) NOT NULL PRIMARY KEY,
+	payload TEXT NOT NULL,
+	headers JSONB NULL,
+	ordering_key VARCHAR(255) NOT NULL DEFAULT '',
+	status VARCHAR(50) NOT NULL,
+	attempts SMALLINT NOT NULL DEFAULT 0,
+	available_at TIMESTAMPTZ NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL,
+	picked_at TIMESTAMPTZ NULL,
+	archived_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS outbox_archive_archived_at_idx ON outbox_archive (archived_at);
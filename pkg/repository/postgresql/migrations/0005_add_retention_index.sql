@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS outbox_status_created_at_idx ON outbox (status, created_at);
@@ -0,0 +1,2 @@
+-- Lets fetches find an unsent message ahead of another one with the same ordering key.
+CREATE INDEX IF NOT EXISTS outbox_unsent_ordering_key_idx ON outbox (ordering_key, created_at) WHERE status <> 'sent';
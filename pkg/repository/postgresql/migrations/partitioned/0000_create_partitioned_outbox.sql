@@ -0,0 +1,21 @@
+-- Creates the outbox range-partitioned by created_at, before the regular migrations add the
+-- remaining columns and indexes to it. The primary key of a partitioned table must contain the
+-- partition key, so IDs are kept unique by the next migration instead.
+CREATE TABLE IF NOT EXISTS outbox (
+	id VARCHAR(255) NOT NULL,
+	payload TEXT NOT NULL,
+	headers JSONB NULL,
+	ordering_key VARCHAR(255) NOT NULL DEFAULT '',
+	status VARCHAR(50) NOT NULL,
+	attempts SMALLINT NOT NULL DEFAULT 0,
+	available_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	picked_at TIMESTAMPTZ NULL,
+	PRIMARY KEY (id, created_at)
+) PARTITION BY RANGE (created_at);
+
+-- Catches messages when no partition was created for their time, so saving never fails.
+CREATE TABLE IF NOT EXISTS outbox_default PARTITION OF outbox DEFAULT;
+
+-- Only unfinished messages are indexed, so fetching probes the old partitions at almost no cost.
+CREATE INDEX IF NOT EXISTS outbox_unfinished_available_at_idx ON outbox (available_at, created_at) WHERE status IN ('pending', 'processing');
@@ -0,0 +1,38 @@
+-- Keeps message IDs unique across the partitions, which the primary key of a partitioned outbox
+-- cannot: every saved ID is registered, so saving an ID that is still in the outbox fails with a
+-- unique violation, and deleting the message releases its ID. Messages are updated, deleted and
+-- archived by ID alone, which would otherwise affect every message sharing it.
+CREATE TABLE IF NOT EXISTS outbox_message_ids (
+	id VARCHAR(255) NOT NULL PRIMARY KEY
+);
+
+CREATE OR REPLACE FUNCTION outbox_register_message_id() RETURNS trigger AS $$
+BEGIN
+	INSERT INTO outbox_message_ids (id) VALUES (NEW.id);
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION outbox_release_message_id() RETURNS trigger AS $$
+BEGIN
+	DELETE FROM outbox_message_ids WHERE id = OLD.id;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- An unpartitioned outbox left in place by these migrations already has a unique primary key.
+DO $$
+BEGIN
+	IF EXISTS (SELECT 1 FROM pg_class WHERE oid = 'outbox'::regclass AND relkind = 'p') THEN
+		INSERT INTO outbox_message_ids (id) SELECT DISTINCT id FROM outbox ON CONFLICT DO NOTHING;
+
+		DROP TRIGGER IF EXISTS outbox_register_message_id ON outbox;
+		CREATE TRIGGER outbox_register_message_id AFTER INSERT ON outbox
+			FOR EACH ROW EXECUTE FUNCTION outbox_register_message_id();
+
+		DROP TRIGGER IF EXISTS outbox_release_message_id ON outbox;
+		CREATE TRIGGER outbox_release_message_id AFTER DELETE ON outbox
+			FOR EACH ROW EXECUTE FUNCTION outbox_release_message_id();
+	END IF;
+END;
+$$;
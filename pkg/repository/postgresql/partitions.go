@@ -0,0 +1,334 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type PartitionLeaseConfigs struct {
+	InstanceID     string        // Unique and stable ID of this dispatcher instance, such as the pod name.
+	PartitionCount uint32        // Number of partitions. Must be above zero and match PostgresConfigs.PartitionCount.
+	LeaseDuration  time.Duration // How long leases and the instance registration last without renewal. Zero uses the default.
+	RenewInterval  time.Duration // How often leases are renewed and partitions rebalanced. Must be well below LeaseDuration. Zero uses a third of LeaseDuration.
+}
+
+func DefaultPartitionLeaseConfigs(instanceID string, partitionCount uint32) PartitionLeaseConfigs {
+	return PartitionLeaseConfigs{
+		InstanceID:     instanceID,
+		PartitionCount: partitionCount,
+		LeaseDuration:  15 * time.Second,
+		RenewInterval:  5 * time.Second,
+	}
+}
+
+// PartitionLeaser assigns partitions to dispatcher instances through lease rows, so every
+// partition, and with it every ordering key, is dispatched by at most one instance at a time.
+//
+// Instances register in outbox_dispatchers and split the partitions evenly between the live ones.
+// When instances join or die, partitions move: the previous owner stops fetching a partition one
+// round before it releases the lease, giving its in-flight messages time to finish, and a new
+// owner can only lease a partition once it was released or its lease expired.
+type PartitionLeaser struct {
+	db      SQLExecutor
+	configs PartitionLeaseConfigs
+
+	mu         sync.Mutex
+	partitions []uint32
+	validUntil time.Time
+	draining   map[uint32]bool
+	now        func() time.Time
+}
+
+func NewPartitionLeaser(db SQLExecutor, configs PartitionLeaseConfigs) (*PartitionLeaser, error) {
+	// Without partitions every instance would lease nothing and never fetch.
+	if configs.PartitionCount == 0 {
+		return nil, fmt.Errorf("partition count must be above zero")
+	}
+
+	if configs.LeaseDuration <= 0 {
+		configs.LeaseDuration = DefaultPartitionLeaseConfigs(configs.InstanceID, configs.PartitionCount).LeaseDuration
+	}
+
+	if configs.RenewInterval <= 0 {
+		configs.RenewInterval = configs.LeaseDuration / 3
+	}
+
+	// Leases renewed less often than they expire are lost between rounds.
+	if configs.RenewInterval >= configs.LeaseDuration {
+		return nil, fmt.Errorf("renew interval %s must be below lease duration %s", configs.RenewInterval, configs.LeaseDuration)
+	}
+
+	return &PartitionLeaser{
+		db:       db,
+		configs:  configs,
+		draining: make(map[uint32]bool),
+		now:      time.Now,
+	}, nil
+}
+
+// NewPartitionedPostgresRepository creates a PartitionLeaser and a repository that only fetches
+// the partitions it leases. Both must agree on the partition count: with fewer leased partitions
+// than messages are hashed into, some messages are never dispatched, and with more, partitions are
+// leased that hold no messages. Set the leaser as DispatcherConfigs.PartitionLeaser so the
+// dispatcher runs it, or run it alongside the dispatcher.
+func NewPartitionedPostgresRepository(db SQLExecutor, configs PostgresConfigs, leaseConfigs PartitionLeaseConfigs) (*PostgresRepository, *PartitionLeaser, error) {
+	if leaseConfigs.PartitionCount != configs.PartitionCount {
+		return nil, nil, fmt.Errorf("lease partition count %d does not match repository partition count %d", leaseConfigs.PartitionCount, configs.PartitionCount)
+	}
+
+	repository, err := NewPostgresRepositoryWithConfigs(db, configs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	leaser, err := NewPartitionLeaser(db, leaseConfigs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return repository.WithPartitions(leaser), leaser, nil
+}
+
+// Run renews leases and rebalances partitions until ctx is done. It then releases the leases
+// and deregisters the instance, so the remaining instances take over without waiting for the
+// leases to expire. Failed rounds do not stop it; the partitions are dropped once their lease
+// could not be renewed in time.
+func (l *PartitionLeaser) Run(ctx context.Context) error {
+	ticker := time.NewTicker(l.configs.RenewInterval)
+	defer ticker.Stop()
+
+	for {
+		_ = l.Rebalance(ctx)
+
+		select {
+		case <-ctx.Done():
+			l.release()
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Partitions returns the partitions this instance owns. It returns none once the leases could
+// not be renewed for LeaseDuration, as other instances may own them by then.
+func (l *PartitionLeaser) Partitions() []uint32 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.now().After(l.validUntil) {
+		return nil
+	}
+
+	return append([]uint32(nil), l.partitions...)
+}
+
+// Rebalance runs a single round: it registers the instance, releases the partitions that
+// drained since the previous round, and leases its share of the partitions.
+func (l *PartitionLeaser) Rebalance(ctx context.Context) error {
+	// Leases are granted after this point, so they outlive the local validity.
+	startedAt := l.now()
+	leaseSeconds := l.configs.LeaseDuration.Seconds()
+
+	_, err := l.db.ExecContext(ctx, `
+		INSERT INTO outbox_dispatchers (instance_id, expires_at)
+		VALUES ($1, NOW() + make_interval(secs => $2))
+		ON CONFLICT (instance_id) DO UPDATE SET expires_at = EXCLUDED.expires_at`,
+		l.configs.InstanceID, leaseSeconds)
+	if err != nil {
+		return fmt.Errorf("failed to register dispatcher instance: %w", err)
+	}
+
+	if _, err := l.db.ExecContext(ctx, "DELETE FROM outbox_dispatchers WHERE expires_at < NOW()"); err != nil {
+		return fmt.Errorf("failed to remove expired dispatcher instances: %w", err)
+	}
+
+	instances, err := l.liveInstances(ctx)
+	if err != nil {
+		return err
+	}
+
+	desired := assignPartitions(l.configs.InstanceID, instances, l.configs.PartitionCount)
+
+	owned, err := l.ownedPartitions(ctx)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	var drained []uint32
+	renew := append([]uint32(nil), desired...)
+	draining := make(map[uint32]bool)
+	for _, partition := range owned {
+		if contains(desired, partition) {
+			continue
+		}
+		if l.draining[partition] {
+			drained = append(drained, partition)
+			continue
+		}
+		// Stop fetching now and release the lease next round.
+		draining[partition] = true
+		renew = append(renew, partition)
+	}
+	l.mu.Unlock()
+
+	if len(drained) > 0 {
+		_, err := l.db.ExecContext(ctx,
+			"DELETE FROM outbox_partition_leases WHERE instance_id = $1 AND partition_id = ANY($2::integer[])",
+			l.configs.InstanceID, partitionArray(drained))
+		if err != nil {
+			return fmt.Errorf("failed to release partitions: %w", err)
+		}
+	}
+
+	// Renews the leases of this instance and takes over released or expired ones. Partitions
+	// still leased by another instance stay with it until it releases them.
+	rows, err := l.db.QueryContext(ctx, `
+		INSERT INTO outbox_partition_leases (partition_id, instance_id, expires_at)
+		SELECT partition_id, $1, NOW() + make_interval(secs => $2)
+		FROM unnest($3::integer[]) AS partition_id
+		ON CONFLICT (partition_id) DO UPDATE
+		SET instance_id = EXCLUDED.instance_id, expires_at = EXCLUDED.expires_at
+		WHERE outbox_partition_leases.instance_id = EXCLUDED.instance_id
+			OR outbox_partition_leases.expires_at < NOW()
+		RETURNING partition_id`,
+		l.configs.InstanceID, leaseSeconds, partitionArray(renew))
+	if err != nil {
+		return fmt.Errorf("failed to lease partitions: %w", err)
+	}
+
+	leased, err := scanPartitions(rows)
+	if err != nil {
+		return fmt.Errorf("failed to lease partitions: %w", err)
+	}
+
+	var partitions []uint32
+	for _, partition := range leased {
+		if !draining[partition] {
+			partitions = append(partitions, partition)
+		}
+	}
+
+	sort.Slice(partitions, func(i, j int) bool { return partitions[i] < partitions[j] })
+
+	l.mu.Lock()
+	l.partitions = partitions
+	l.draining = draining
+	l.validUntil = startedAt.Add(l.configs.LeaseDuration)
+	l.mu.Unlock()
+
+	return nil
+}
+
+func (l *PartitionLeaser) liveInstances(ctx context.Context) ([]string, error) {
+	rows, err := l.db.QueryContext(ctx, "SELECT instance_id FROM outbox_dispatchers WHERE expires_at > NOW()")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch dispatcher instances: %w", err)
+	}
+
+	defer rows.Close()
+
+	var instances []string
+
+	for rows.Next() {
+		var instance string
+		if err := rows.Scan(&instance); err != nil {
+			return nil, fmt.Errorf("failed to fetch dispatcher instances: %w", err)
+		}
+		instances = append(instances, instance)
+	}
+
+	return instances, rows.Err()
+}
+
+func (l *PartitionLeaser) ownedPartitions(ctx context.Context) ([]uint32, error) {
+	rows, err := l.db.QueryContext(ctx,
+		"SELECT partition_id FROM outbox_partition_leases WHERE instance_id = $1 AND expires_at > NOW()",
+		l.configs.InstanceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch leased partitions: %w", err)
+	}
+
+	partitions, err := scanPartitions(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch leased partitions: %w", err)
+	}
+
+	return partitions, nil
+}
+
+// release gives up every lease and the registration when the leaser stops.
+func (l *PartitionLeaser) release() {
+	l.mu.Lock()
+	l.partitions = nil
+	l.draining = make(map[uint32]bool)
+	l.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), l.configs.RenewInterval)
+	defer cancel()
+
+	_, _ = l.db.ExecContext(ctx, "DELETE FROM outbox_partition_leases WHERE instance_id = $1", l.configs.InstanceID)
+	_, _ = l.db.ExecContext(ctx, "DELETE FROM outbox_dispatchers WHERE instance_id = $1", l.configs.InstanceID)
+}
+
+// assignPartitions deals the partitions round-robin to the live instances sorted by ID, so every
+// instance computes the same assignment from the same registrations. An instance that is not
+// registered gets none.
+func assignPartitions(instanceID string, instances []string, partitionCount uint32) []uint32 {
+	sorted := append([]string(nil), instances...)
+	sort.Strings(sorted)
+
+	index := sort.SearchStrings(sorted, instanceID)
+	if index == len(sorted) || sorted[index] != instanceID {
+		return nil
+	}
+
+	var partitions []uint32
+	for partition := uint32(index); partition < partitionCount; partition += uint32(len(sorted)) {
+		partitions = append(partitions, partition)
+	}
+
+	return partitions
+}
+
+func scanPartitions(rows *sql.Rows) ([]uint32, error) {
+	defer rows.Close()
+
+	var partitions []uint32
+
+	for rows.Next() {
+		var partition uint32
+		if err := rows.Scan(&partition); err != nil {
+			return nil, err
+		}
+		partitions = append(partitions, partition)
+	}
+
+	return partitions, rows.Err()
+}
+
+// partitionArray converts partitions to an integer[] parameter.
+func partitionArray(partitions []uint32) interface{} {
+	ids := make([]int64, 0, len(partitions))
+	for _, partition := range partitions {
+		ids = append(ids, int64(partition))
+	}
+
+	return pq.Array(ids)
+}
+
+func contains(partitions []uint32, partition uint32) bool {
+	for _, p := range partitions {
+		if p == partition {
+			return true
+		}
+	}
+
+	return false
+}
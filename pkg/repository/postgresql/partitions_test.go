@@ -0,0 +1,130 @@
+package postgresql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssignPartitions(t *testing.T) {
+	instances := []string{"c", "a", "b"}
+
+	assert.Equal(t, []uint32{0, 3, 6}, assignPartitions("a", instances, 8))
+	assert.Equal(t, []uint32{1, 4, 7}, assignPartitions("b", instances, 8))
+	assert.Equal(t, []uint32{2, 5}, assignPartitions("c", instances, 8))
+	assert.Empty(t, assignPartitions("d", instances, 8), "Unregistered instances must not get partitions")
+}
+
+func TestPartitionLeaser_Partitions_ExpireWithoutRenewal(t *testing.T) {
+	now := time.Now()
+
+	leaser, err := NewPartitionLeaser(nil, DefaultPartitionLeaseConfigs("a", 4))
+	require.NoError(t, err)
+	leaser.now = func() time.Time { return now }
+	leaser.partitions = []uint32{0, 1}
+	leaser.validUntil = now.Add(time.Second)
+
+	assert.Equal(t, []uint32{0, 1}, leaser.Partitions())
+
+	now = now.Add(2 * time.Second)
+	assert.Empty(t, leaser.Partitions(), "Partitions must be dropped once the lease could not be renewed")
+}
+
+func TestNewPartitionLeaser_DefaultsIntervals(t *testing.T) {
+	leaser, err := NewPartitionLeaser(nil, PartitionLeaseConfigs{InstanceID: "a", PartitionCount: 4})
+	require.NoError(t, err)
+	assert.Equal(t, 15*time.Second, leaser.configs.LeaseDuration)
+	assert.Equal(t, 5*time.Second, leaser.configs.RenewInterval)
+
+	leaser, err = NewPartitionLeaser(nil, PartitionLeaseConfigs{InstanceID: "a", PartitionCount: 4, LeaseDuration: 3 * time.Second})
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, leaser.configs.RenewInterval)
+}
+
+func TestNewPartitionLeaser_RejectsRenewIntervalNotBelowLeaseDuration(t *testing.T) {
+	configs := DefaultPartitionLeaseConfigs("a", 4)
+	configs.RenewInterval = configs.LeaseDuration
+
+	_, err := NewPartitionLeaser(nil, configs)
+
+	assert.EqualError(t, err, "renew interval 15s must be below lease duration 15s")
+}
+
+func TestNewPartitionLeaser_RejectsZeroPartitionCount(t *testing.T) {
+	_, err := NewPartitionLeaser(nil, DefaultPartitionLeaseConfigs("a", 0))
+
+	assert.EqualError(t, err, "partition count must be above zero")
+}
+
+func TestNewPartitionedPostgresRepository(t *testing.T) {
+	repo, leaser, err := NewPartitionedPostgresRepository(nil, PostgresConfigs{PartitionCount: 4}, DefaultPartitionLeaseConfigs("a", 4))
+	require.NoError(t, err)
+	assert.Same(t, leaser, repo.partitions)
+
+	_, _, err = NewPartitionedPostgresRepository(nil, PostgresConfigs{PartitionCount: 4}, DefaultPartitionLeaseConfigs("a", 8))
+	assert.EqualError(t, err, "lease partition count 8 does not match repository partition count 4")
+}
+
+func setupLeasers(t *testing.T, instanceIDs ...string) []*PartitionLeaser {
+	if testDB == nil {
+		t.Skip("PostgreSQL test database is unavailable")
+	}
+
+	_, err := testDB.Exec(`TRUNCATE outbox_dispatchers, outbox_partition_leases`)
+	require.NoError(t, err)
+
+	var leasers []*PartitionLeaser
+	for _, instanceID := range instanceIDs {
+		leaser, err := NewPartitionLeaser(testDB, DefaultPartitionLeaseConfigs(instanceID, 4))
+		require.NoError(t, err)
+		leasers = append(leasers, leaser)
+	}
+
+	return leasers
+}
+
+func assertExclusive(t *testing.T, leasers ...*PartitionLeaser) {
+	owners := make(map[uint32]string)
+	for _, leaser := range leasers {
+		for _, partition := range leaser.Partitions() {
+			if owner, ok := owners[partition]; ok {
+				t.Errorf("Partition %d is owned by %s and %s", partition, owner, leaser.configs.InstanceID)
+			}
+			owners[partition] = leaser.configs.InstanceID
+		}
+	}
+}
+
+func TestPartitionLeaser_RebalancesWhenInstancesJoinAndLeave(t *testing.T) {
+	leasers := setupLeasers(t, "a", "b")
+	a, b := leasers[0], leasers[1]
+	ctx := context.Background()
+
+	require.NoError(t, a.Rebalance(ctx))
+	assert.Equal(t, []uint32{0, 1, 2, 3}, a.Partitions())
+
+	// b joins, but a still holds every lease.
+	require.NoError(t, b.Rebalance(ctx))
+	assert.Empty(t, b.Partitions())
+
+	// a stops fetching b's share first, then releases it a round later.
+	require.NoError(t, a.Rebalance(ctx))
+	assert.Equal(t, []uint32{0, 2}, a.Partitions())
+	require.NoError(t, b.Rebalance(ctx))
+	assert.Empty(t, b.Partitions())
+	assertExclusive(t, a, b)
+
+	require.NoError(t, a.Rebalance(ctx))
+	require.NoError(t, b.Rebalance(ctx))
+	assert.Equal(t, []uint32{0, 2}, a.Partitions())
+	assert.Equal(t, []uint32{1, 3}, b.Partitions())
+	assertExclusive(t, a, b)
+
+	// a leaves, and b takes over its partitions.
+	a.release()
+	require.NoError(t, b.Rebalance(ctx))
+	assert.Equal(t, []uint32{0, 1, 2, 3}, b.Partitions())
+}
@@ -3,7 +3,10 @@ package postgresql
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"go-transactional-outbox/pkg/core"
+	"hash/fnv"
 	"time"
 )
 
@@ -13,58 +16,179 @@ type SQLExecutor interface {
 	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
 }
 
+type txBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// withTx runs fn in a transaction when db can begin one. Otherwise db already is a transaction,
+// or the caller manages it, and fn runs on it directly.
+func withTx(ctx context.Context, db SQLExecutor, fn func(db SQLExecutor) error) error {
+	beginner, ok := db.(txBeginner)
+	if !ok {
+		return fn(db)
+	}
+
+	tx, err := beginner.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SentMessagePolicy decides what happens to a message's row once it was sent.
+type SentMessagePolicy string
+
+const (
+	SentMessagePolicyKeep    SentMessagePolicy = "keep"    // The row stays in the outbox with status sent.
+	SentMessagePolicyDelete  SentMessagePolicy = "delete"  // The row is deleted, with its delivery records.
+	SentMessagePolicyArchive SentMessagePolicy = "archive" // The row is moved to outbox_archive, and its delivery records are deleted.
+)
+
+type PostgresConfigs struct {
+	NotifyChannel  string            // Channel notified with the message ID of every saved message. Empty disables notifications.
+	PartitionCount uint32            // Number of partitions messages are hashed into by ordering key. Zero stores every message in partition 0.
+	OnSent         SentMessagePolicy // What happens to sent messages. Empty keeps them.
+
+	// DebeziumColumns also fills the aggregatetype, aggregateid and type columns read by
+	// Debezium's outbox event router, from the aggregate-type header, the ordering key and the
+	// event-type header. Messages without an aggregate type are rejected, as Debezium routes by it.
+	DebeziumColumns bool
+}
+
+func DefaultPostgresConfigs() PostgresConfigs {
+	return PostgresConfigs{
+		OnSent: SentMessagePolicyKeep,
+	}
+}
+
+// PartitionAssignment reports the partitions a dispatcher instance currently owns.
+type PartitionAssignment interface {
+	Partitions() []uint32
+}
+
 type PostgresRepository struct {
-	db SQLExecutor
+	db         SQLExecutor
+	configs    PostgresConfigs
+	partitions PartitionAssignment
+}
+
+func NewPostgresRepository(db SQLExecutor) *PostgresRepository {
+	return &PostgresRepository{
+		db:      db,
+		configs: DefaultPostgresConfigs(),
+	}
+}
+
+func NewPostgresRepositoryWithConfigs(db SQLExecutor, configs PostgresConfigs) (*PostgresRepository, error) {
+	switch configs.OnSent {
+	case "", SentMessagePolicyKeep, SentMessagePolicyDelete, SentMessagePolicyArchive:
+	default:
+		return nil, fmt.Errorf("unknown sent message policy %q", configs.OnSent)
+	}
+
+	return &PostgresRepository{
+		db:      db,
+		configs: configs,
+	}, nil
+}
+
+// WithPartitions returns a repository that only fetches messages of the partitions currently
+// owned according to assignment, such as a PartitionLeaser. All other methods are unchanged.
+func (r *PostgresRepository) WithPartitions(assignment PartitionAssignment) *PostgresRepository {
+	partitioned := *r
+	partitioned.partitions = assignment
+
+	return &partitioned
 }
 
 func (r *PostgresRepository) SaveMessage(ctx context.Context, message core.OutboxMessage) error {
-	_, err := r.db.ExecContext(ctx,
-		"INSERT INTO outbox (id, payload, status, attempts, available_at, created_at) VALUES ($1, $2, $3, 0, NOW(), NOW())",
-		message.ID, message.Payload, message.Status)
+	headers, err := encodeHeaders(message.Headers)
+	if err != nil {
+		return err
+	}
+
+	query := "INSERT INTO outbox (id, payload, headers, ordering_key, status, attempts, available_at, created_at, partition_id) VALUES ($1, $2, $3, $4, $5, 0, NOW() + make_interval(secs => $6), NOW(), $7)"
+	args := []interface{}{message.ID, message.Payload, headers, message.OrderingKey, message.Status, scheduleDelay(message.AvailableAt).Seconds(), r.partitionOf(message)}
+
+	if r.configs.DebeziumColumns {
+		if message.Headers[core.HeaderAggregateType] == "" {
+			return fmt.Errorf("failed to save message %s: the %s header is required for Debezium", message.ID, core.HeaderAggregateType)
+		}
+
+		query = "INSERT INTO outbox (id, payload, headers, ordering_key, status, attempts, available_at, created_at, partition_id, aggregatetype, aggregateid, type) VALUES ($1, $2, $3, $4, $5, 0, NOW() + make_interval(secs => $6), NOW(), $7, $8, $9, $10)"
+		args = append(args, message.Headers[core.HeaderAggregateType], message.OrderingKey, nullIfEmpty(message.Headers[core.HeaderEventType]))
+	}
+
+	// Notifications are sent when the surrounding transaction commits, so listeners never
+	// fetch before the message is visible.
+	if r.configs.NotifyChannel != "" {
+		query = "WITH inserted AS (" + query + " RETURNING id) SELECT pg_notify($" + fmt.Sprint(len(args)+1) + ", id) FROM inserted"
+		args = append(args, r.configs.NotifyChannel)
+	}
+
+	_, err = r.db.ExecContext(ctx, query, args...)
 	return err
 }
 
 func (r *PostgresRepository) FetchPendingMessages(ctx context.Context, limit uint32, processingLockTimeout uint32) ([]core.OutboxMessage, error) {
-	query := `
-		WITH selected_messages AS (
-			SELECT *
-			FROM outbox
-			WHERE status = '$1' AND available_at <= NOW()
-			ORDER BY available_at ASC
-			LIMIT $2
-			FOR UPDATE SKIP LOCKED
+	partitionFilter := ""
+	args := []interface{}{
+		core.MessageStatusPending,
+		core.MessageStatusProcessing,
+		processingLockTimeout,
+		limit,
+		core.MessageStatusSent,
+	}
+
+	if r.partitions != nil {
+		partitions := r.partitions.Partitions()
+		if len(partitions) == 0 {
+			return nil, nil
+		}
 
-			UNION ALL
+		partitionFilter = "AND partition_id = ANY($6::integer[])"
+		args = append(args, partitionArray(partitions))
+	}
 
-			SELECT *
+	// Only the head of an ordering key is fetched: a message waits while an older message of its
+	// key is unsent, such as one waiting for a retry. A failed message therefore blocks its key
+	// until it is retried or removed, so consumers never receive an event without the ones before it.
+	query := `
+		WITH selected_messages AS (
+			SELECT id, created_at
 			FROM outbox
-			WHERE status = '$3' AND available_at <= NOW() AND picked_at < NOW() - INTERVAL '$4 seconds'
-			ORDER BY available_at ASC
-			LIMIT $5
+			WHERE available_at <= NOW()
+				AND (status = $1 OR (status = $2 AND picked_at < NOW() - make_interval(secs => $3)))
+				AND (ordering_key = '' OR NOT EXISTS (
+					SELECT 1
+					FROM outbox AS earlier
+					WHERE earlier.ordering_key = outbox.ordering_key
+						AND earlier.status <> $5
+						AND earlier.created_at < outbox.created_at
+				))
+				` + partitionFilter + `
+			ORDER BY available_at ASC, created_at ASC
+			LIMIT $4
 			FOR UPDATE SKIP LOCKED
-		)
-		UPDATE outbox
-		SET status = '$6', picked_at = NOW()
-		WHERE id IN (
-			SELECT id
+		), claimed_messages AS (
+			UPDATE outbox
+			SET status = $2, picked_at = NOW()
 			FROM selected_messages
-			ORDER BY available_at ASC
-			LIMIT $7
+			WHERE outbox.id = selected_messages.id AND outbox.created_at = selected_messages.created_at
+			RETURNING outbox.id, outbox.payload, outbox.headers, outbox.ordering_key, outbox.status, outbox.attempts, outbox.available_at, outbox.created_at
 		)
-		RETURNING id, payload, status, attempts;
+		SELECT id, payload, headers, ordering_key, status, attempts
+		FROM claimed_messages
+		ORDER BY available_at ASC, created_at ASC;
 	`
 
-	rows, err := r.db.QueryContext(
-		ctx,
-		query,
-		core.MessageStatusPending,
-		limit,
-		core.MessageStatusProcessing,
-		processingLockTimeout,
-		limit,
-		core.MessageStatusProcessing,
-		limit,
-	)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 
 	if err != nil {
 		return nil, err
@@ -76,16 +200,106 @@ func (r *PostgresRepository) FetchPendingMessages(ctx context.Context, limit uin
 
 	for rows.Next() {
 		var message core.OutboxMessage
-		if err := rows.Scan(&message.ID, &message.Payload, &message.Status, &message.Attempts); err != nil {
+		var headers []byte
+		if err := rows.Scan(&message.ID, &message.Payload, &headers, &message.OrderingKey, &message.Status, &message.Attempts); err != nil {
+			return nil, err
+		}
+		if message.Headers, err = decodeHeaders(headers); err != nil {
 			return nil, err
 		}
 		messages = append(messages, message)
 	}
 
-	return messages, nil
+	return messages, rows.Err()
+}
+
+// ClaimMessage locks a single pending and available message for processing, as
+// FetchPendingMessages does for a batch. It returns false when the message does not exist, is
+// not available yet, or was already claimed.
+func (r *PostgresRepository) ClaimMessage(ctx context.Context, id string) (core.OutboxMessage, bool, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		UPDATE outbox
+		SET status = $1, picked_at = NOW()
+		WHERE id = $2 AND status = $3 AND available_at <= NOW()
+		RETURNING id, payload, headers, ordering_key, status, attempts`,
+		core.MessageStatusProcessing,
+		id,
+		core.MessageStatusPending,
+	)
+	if err != nil {
+		return core.OutboxMessage{}, false, err
+	}
+
+	defer rows.Close()
+
+	if !rows.Next() {
+		return core.OutboxMessage{}, false, rows.Err()
+	}
+
+	var message core.OutboxMessage
+	var headers []byte
+	if err := rows.Scan(&message.ID, &message.Payload, &headers, &message.OrderingKey, &message.Status, &message.Attempts); err != nil {
+		return core.OutboxMessage{}, false, err
+	}
+
+	if message.Headers, err = decodeHeaders(headers); err != nil {
+		return core.OutboxMessage{}, false, err
+	}
+
+	return message, true, nil
 }
 
+// archiveOnConflict replaces an archived message whose ID was reused by a newer message. Doing
+// nothing instead would delete the newer message from the outbox without archiving it. IDs are
+// unique within the outbox, also when it is partitioned, so no statement archives an ID twice.
+const archiveOnConflict = `ON CONFLICT (id) DO UPDATE SET
+	payload = EXCLUDED.payload,
+	headers = EXCLUDED.headers,
+	ordering_key = EXCLUDED.ordering_key,
+	status = EXCLUDED.status,
+	attempts = EXCLUDED.attempts,
+	available_at = EXCLUDED.available_at,
+	created_at = EXCLUDED.created_at,
+	picked_at = EXCLUDED.picked_at,
+	archived_at = EXCLUDED.archived_at`
+
+// MarkMessageAsSent applies the configured SentMessagePolicy. Deleting and archiving are single
+// statements, so a message is never both in the outbox and the archive.
 func (r *PostgresRepository) MarkMessageAsSent(ctx context.Context, id string, shouldIncrementAttempts bool) error {
+	switch r.configs.OnSent {
+	case SentMessagePolicyDelete:
+		_, err := r.db.ExecContext(ctx, `
+			WITH deleted_deliveries AS (
+				DELETE FROM outbox_deliveries WHERE message_id = $1
+			)
+			DELETE FROM outbox WHERE id = $1`,
+			id,
+		)
+		return err
+	case SentMessagePolicyArchive:
+		increment := 0
+		if shouldIncrementAttempts {
+			increment = 1
+		}
+
+		_, err := r.db.ExecContext(ctx, `
+			WITH deleted_deliveries AS (
+				DELETE FROM outbox_deliveries WHERE message_id = $1
+			), archived_messages AS (
+				DELETE FROM outbox WHERE id = $1
+				RETURNING id, payload, headers, ordering_key, attempts, available_at, created_at, picked_at
+			)
+			INSERT INTO outbox_archive (id, payload, headers, ordering_key, status, attempts, available_at, created_at, picked_at, archived_at)
+			SELECT id, payload, headers, ordering_key, $2, attempts + $3, available_at, created_at, picked_at, NOW()
+			FROM archived_messages
+			`+archiveOnConflict,
+			id,
+			core.MessageStatusSent,
+			increment,
+		)
+		return err
+	}
+
 	return r.updateMessageStatus(ctx, id, core.MessageStatusSent, shouldIncrementAttempts)
 }
 
@@ -94,13 +308,13 @@ func (r *PostgresRepository) MarkMessageAsFailed(ctx context.Context, id string,
 }
 
 func (r *PostgresRepository) MarkMessageForRetry(ctx context.Context, id string, delay time.Duration, shouldIncrementAttempts bool) error {
-	query := "UPDATE outbox SET status = '$1'"
+	query := "UPDATE outbox SET status = $1"
 
 	if shouldIncrementAttempts {
 		query += ", attempts = attempts + 1"
 	}
 
-	query += ", available_at = (NOW() + ($2 || ' seconds')::INTERVAL)"
+	query += ", available_at = NOW() + make_interval(secs => $2)"
 
 	query += " WHERE id = $3;"
 
@@ -109,8 +323,98 @@ func (r *PostgresRepository) MarkMessageForRetry(ctx context.Context, id string,
 	return err
 }
 
+func (r *PostgresRepository) RescheduleMessage(ctx context.Context, id string, availableAt time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE outbox SET available_at = NOW() + make_interval(secs => $1) WHERE id = $2 AND status = $3",
+		scheduleDelay(availableAt).Seconds(), id, core.MessageStatusPending)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := rowsAffected(result)
+
+	return affected > 0, err
+}
+
+// CancelMessage deletes a pending message with its delivery records. A message that is being
+// fetched concurrently stays locked until it is claimed, and is then no longer pending.
+func (r *PostgresRepository) CancelMessage(ctx context.Context, id string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		WITH canceled_messages AS (
+			DELETE FROM outbox WHERE id = $1 AND status = $2
+			RETURNING id
+		), deleted_deliveries AS (
+			DELETE FROM outbox_deliveries
+			WHERE message_id IN (SELECT id FROM canceled_messages)
+		)
+		SELECT id FROM canceled_messages`,
+		id, core.MessageStatusPending,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := rowsAffected(result)
+
+	return affected > 0, err
+}
+
+// DeleteMessages deletes a batch of messages with their delivery records, for a janitor.
+func (r *PostgresRepository) DeleteMessages(ctx context.Context, status core.MessageStatus, createdBefore time.Time, limit uint32) (int, error) {
+	result, err := r.db.ExecContext(ctx, `
+		WITH deleted_messages AS (
+			DELETE FROM outbox
+			WHERE id IN (
+				SELECT id FROM outbox
+				WHERE status = $1 AND created_at < $2
+				LIMIT $3
+			)
+			RETURNING id
+		), deleted_deliveries AS (
+			DELETE FROM outbox_deliveries
+			WHERE message_id IN (SELECT id FROM deleted_messages)
+		)
+		SELECT id FROM deleted_messages`,
+		status, createdBefore, limit,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected(result)
+}
+
+// ArchiveMessages moves a batch of messages to outbox_archive and deletes their delivery records,
+// for a janitor.
+func (r *PostgresRepository) ArchiveMessages(ctx context.Context, status core.MessageStatus, createdBefore time.Time, limit uint32) (int, error) {
+	result, err := r.db.ExecContext(ctx, `
+		WITH archived_messages AS (
+			DELETE FROM outbox
+			WHERE id IN (
+				SELECT id FROM outbox
+				WHERE status = $1 AND created_at < $2
+				LIMIT $3
+			)
+			RETURNING id, payload, headers, ordering_key, status, attempts, available_at, created_at, picked_at
+		), deleted_deliveries AS (
+			DELETE FROM outbox_deliveries
+			WHERE message_id IN (SELECT id FROM archived_messages)
+		)
+		INSERT INTO outbox_archive (id, payload, headers, ordering_key, status, attempts, available_at, created_at, picked_at, archived_at)
+		SELECT id, payload, headers, ordering_key, status, attempts, available_at, created_at, picked_at, NOW()
+		FROM archived_messages
+		`+archiveOnConflict,
+		status, createdBefore, limit,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return rowsAffected(result)
+}
+
 func (r *PostgresRepository) updateMessageStatus(ctx context.Context, id string, status core.MessageStatus, shouldIncrementAttempts bool) error {
-	query := "UPDATE outbox SET status = '$1'"
+	query := "UPDATE outbox SET status = $1"
 
 	if shouldIncrementAttempts {
 		query += ", attempts = attempts + 1"
@@ -122,3 +426,106 @@ func (r *PostgresRepository) updateMessageStatus(ctx context.Context, id string,
 
 	return err
 }
+
+// partitionOf hashes the ordering key, so all messages of a key land in the same partition.
+// Messages without an ordering key are spread by ID. Changing PartitionCount moves keys between
+// partitions, so it must only change while no dispatcher is running.
+func (r *PostgresRepository) partitionOf(message core.OutboxMessage) uint32 {
+	if r.configs.PartitionCount == 0 {
+		return 0
+	}
+
+	key := message.OrderingKey
+	if key == "" {
+		key = message.ID
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(key))
+
+	return hash.Sum32() % r.configs.PartitionCount
+}
+
+// encodeHeaders converts message headers to the JSON stored in the headers column. It returns a
+// string, as lib/pq sends []byte arguments as bytea, which JSONB does not accept.
+func encodeHeaders(headers map[string]string) (interface{}, error) {
+	if len(headers) == 0 {
+		return nil, nil
+	}
+
+	encoded, err := json.Marshal(headers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode message headers: %w", err)
+	}
+
+	return string(encoded), nil
+}
+
+func decodeHeaders(encoded []byte) (map[string]string, error) {
+	if len(encoded) == 0 {
+		return nil, nil
+	}
+
+	var headers map[string]string
+	if err := json.Unmarshal(encoded, &headers); err != nil {
+		return nil, fmt.Errorf("failed to decode message headers: %w", err)
+	}
+
+	return headers, nil
+}
+
+// scheduleDelay converts the time of a scheduled message to a delay from now, so availability is
+// computed by the database's clock like that of retries.
+func scheduleDelay(availableAt time.Time) time.Duration {
+	if availableAt.IsZero() {
+		return 0
+	}
+
+	return time.Until(availableAt)
+}
+
+func rowsAffected(result sql.Result) (int, error) {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(affected), nil
+}
+
+func nullIfEmpty(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+
+	return value
+}
+
+// FetchDeliveredDestinations reads the outbox_deliveries table, which has a primary key on (message_id, destination).
+func (r *PostgresRepository) FetchDeliveredDestinations(ctx context.Context, messageID string) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT destination FROM outbox_deliveries WHERE message_id = $1", messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var destinations []string
+
+	for rows.Next() {
+		var destination string
+		if err := rows.Scan(&destination); err != nil {
+			return nil, err
+		}
+		destinations = append(destinations, destination)
+	}
+
+	return destinations, rows.Err()
+}
+
+func (r *PostgresRepository) MarkDestinationAsDelivered(ctx context.Context, messageID string, destination string) error {
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO outbox_deliveries (message_id, destination, delivered_at) VALUES ($1, $2, NOW()) ON CONFLICT DO NOTHING",
+		messageID, destination)
+	return err
+}
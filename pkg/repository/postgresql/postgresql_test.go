@@ -3,7 +3,9 @@ package postgresql
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"go-transactional-outbox/pkg/core"
+	"go-transactional-outbox/pkg/repository/repositorytest"
 	"log"
 	"os"
 	"testing"
@@ -15,6 +17,7 @@ import (
 )
 
 var testDB *sql.DB
+var testDSN string
 
 func TestMain(m *testing.M) {
 	setupDatabase()
@@ -28,37 +31,56 @@ func TestMain(m *testing.M) {
 }
 
 func setupDatabase() {
-	var err error
+	dsn := os.Getenv("OUTBOX_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		dsn = "host=localhost port=5432 user=postgres password=secret dbname=testdb sslmode=disable"
+	}
 
-	// Connect to PostgreSQL test database
-	testDB, err = sql.Open("postgres", "host=localhost port=5432 user=postgres password=secret dbname=testdb sslmode=disable")
+	testDSN = dsn
 
+	// Connect to PostgreSQL test database
+	db, err := sql.Open("postgres", dsn)
 	if err != nil {
 		log.Fatalf("Failed to connect to test database: %v", err)
 	}
 
-	// Create outbox table
-	_, err = testDB.Exec(`
-		CREATE TABLE IF NOT EXISTS outbox (
-			id SERIAL PRIMARY KEY,
-			payload TEXT NOT NULL,
-			status VARCHAR(50) NOT NULL,
-			created_at TIMESTAMP DEFAULT NOW()
-		)
-	`)
+	// Tests are skipped when no PostgreSQL server is running (see docker-compose.yml).
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
 
-	if err != nil {
-		log.Fatalf("Failed to create test table: %v", err)
+	if err := db.PingContext(ctx); err != nil {
+		log.Printf("Skipping PostgreSQL tests, test database is unavailable: %v", err)
+		_ = db.Close()
+		return
+	}
+
+	// Create outbox tables
+	if err := Migrate(context.Background(), db); err != nil {
+		log.Fatalf("Failed to migrate test database: %v", err)
 	}
+
+	testDB = db
 }
 
 func teardownDatabase() {
+	if testDB == nil {
+		return
+	}
+
+	_, _ = testDB.Exec(`DROP TABLE IF EXISTS outbox_archive`)
+	_, _ = testDB.Exec(`DROP TABLE IF EXISTS outbox_partition_leases`)
+	_, _ = testDB.Exec(`DROP TABLE IF EXISTS outbox_dispatchers`)
+	_, _ = testDB.Exec(`DROP TABLE IF EXISTS outbox_deliveries`)
 	_, _ = testDB.Exec(`DROP TABLE IF EXISTS outbox`)
 
 	testDB.Close()
 }
 
 func setupTest(t *testing.T) (*sql.Tx, context.Context) {
+	if testDB == nil {
+		t.Skip("PostgreSQL test database is unavailable")
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	t.Cleanup(cancel) // Ensure the timeout context is canceled after the test
 
@@ -81,9 +103,7 @@ func setupTest(t *testing.T) (*sql.Tx, context.Context) {
 func TestSaveMessage(t *testing.T) {
 	tx, ctx := setupTest(t)
 
-	repo := PostgresRepository{
-		db: tx,
-	}
+	repo := NewPostgresRepository(tx)
 
 	message := core.OutboxMessage{
 		ID:      "1",
@@ -103,14 +123,13 @@ func TestSaveMessage(t *testing.T) {
 func TestFetchPendingMessages(t *testing.T) {
 	tx, ctx := setupTest(t)
 
-	repo := PostgresRepository{
-		db: tx,
-	}
+	repo := NewPostgresRepository(tx)
 
-	// Insert sample pending messages
+	// Insert sample pending messages. NOW() is fixed within a transaction, so the messages
+	// are made available at distinct times to keep the fetch order deterministic.
 	_, err := tx.ExecContext(ctx, `
-		INSERT INTO outbox (id, payload, status) 
-		VALUES ($1, $2, $3), ($4, $5, $6)`,
+		INSERT INTO outbox (id, payload, status, available_at) 
+		VALUES ($1, $2, $3, NOW() - INTERVAL '2 seconds'), ($4, $5, $6, NOW() - INTERVAL '1 second')`,
 		"2", "Payload 1", core.MessageStatusPending,
 		"3", "Payload 2", core.MessageStatusPending,
 	)
@@ -118,18 +137,49 @@ func TestFetchPendingMessages(t *testing.T) {
 
 	messages, err := repo.FetchPendingMessages(ctx, 10, 30)
 	require.NoError(t, err)
-	assert.Len(t, messages, 2, "Expected 2 pending messages")
+	require.Len(t, messages, 2, "Expected 2 pending messages")
 
 	assert.Equal(t, "Payload 1", messages[0].Payload)
 	assert.Equal(t, "Payload 2", messages[1].Payload)
 }
 
+func TestFetchPendingMessages_WaitsForRetriedHeadOfOrderingKey(t *testing.T) {
+	tx, ctx := setupTest(t)
+
+	repo := NewPostgresRepository(tx)
+
+	// The head of order-1 failed once and waits for its retry, while the next message of the
+	// same key is already available.
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO outbox (id, payload, ordering_key, status, attempts, available_at, created_at)
+		VALUES
+			('head', 'Payload', 'order-1', $1, 1, NOW() + INTERVAL '1 minute', NOW() - INTERVAL '3 seconds'),
+			('next', 'Payload', 'order-1', $1, 0, NOW() - INTERVAL '2 seconds', NOW() - INTERVAL '2 seconds'),
+			('other', 'Payload', 'order-2', $1, 0, NOW() - INTERVAL '2 seconds', NOW() - INTERVAL '2 seconds'),
+			('unkeyed', 'Payload', '', $1, 0, NOW() - INTERVAL '1 second', NOW() - INTERVAL '1 second')`,
+		core.MessageStatusPending,
+	)
+	require.NoError(t, err)
+
+	messages, err := repo.FetchPendingMessages(ctx, 10, 30)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "other", messages[0].ID)
+	assert.Equal(t, "unkeyed", messages[1].ID)
+
+	_, err = tx.ExecContext(ctx, `UPDATE outbox SET status = $1 WHERE id = 'head'`, core.MessageStatusSent)
+	require.NoError(t, err)
+
+	messages, err = repo.FetchPendingMessages(ctx, 10, 30)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, "next", messages[0].ID, "The next message is fetched once the head was sent")
+}
+
 func TestMarkMessageAsSent(t *testing.T) {
 	tx, ctx := setupTest(t)
 
-	repo := PostgresRepository{
-		db: tx,
-	}
+	repo := NewPostgresRepository(tx)
 
 	// Insert a pending message
 	_, err := tx.ExecContext(ctx, `
@@ -140,22 +190,192 @@ func TestMarkMessageAsSent(t *testing.T) {
 	require.NoError(t, err)
 
 	// Mark message as sent
-	err = repo.MarkMessageAsSent(ctx, "4")
+	err = repo.MarkMessageAsSent(ctx, "4", true)
 	require.NoError(t, err)
 
 	// Verify in database
-	var status string
+	var status core.MessageStatus
 	err = tx.QueryRowContext(ctx, `SELECT status FROM outbox WHERE id = $1`, "4").Scan(&status)
 	require.NoError(t, err)
 	assert.Equal(t, core.MessageStatusSent, status, "Message status was not updated to sent")
 }
 
-func TestMarkMessageAsFailed(t *testing.T) {
+func TestMarkMessageAsSent_Delete(t *testing.T) {
 	tx, ctx := setupTest(t)
 
-	repo := PostgresRepository{
-		db: tx,
+	repo, err := NewPostgresRepositoryWithConfigs(tx, PostgresConfigs{OnSent: SentMessagePolicyDelete})
+	require.NoError(t, err)
+
+	require.NoError(t, repo.SaveMessage(ctx, core.OutboxMessage{ID: "delete-1", Payload: "Payload", Status: core.MessageStatusPending}))
+	require.NoError(t, repo.MarkDestinationAsDelivered(ctx, "delete-1", "sqs"))
+
+	require.NoError(t, repo.MarkMessageAsSent(ctx, "delete-1", true))
+
+	var count int
+	require.NoError(t, tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM outbox WHERE id = $1`, "delete-1").Scan(&count))
+	assert.Equal(t, 0, count, "Sent message was not deleted")
+
+	destinations, err := repo.FetchDeliveredDestinations(ctx, "delete-1")
+	require.NoError(t, err)
+	assert.Empty(t, destinations)
+}
+
+func TestMarkMessageAsSent_Archive(t *testing.T) {
+	tx, ctx := setupTest(t)
+
+	repo, err := NewPostgresRepositoryWithConfigs(tx, PostgresConfigs{OnSent: SentMessagePolicyArchive})
+	require.NoError(t, err)
+
+	require.NoError(t, repo.SaveMessage(ctx, core.OutboxMessage{ID: "archive-1", Payload: "Payload", Headers: map[string]string{"event-type": "order.created"}, Status: core.MessageStatusPending}))
+
+	require.NoError(t, repo.MarkMessageAsSent(ctx, "archive-1", true))
+
+	var count int
+	require.NoError(t, tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM outbox WHERE id = $1`, "archive-1").Scan(&count))
+	assert.Equal(t, 0, count, "Sent message was not moved")
+
+	var status core.MessageStatus
+	var attempts int
+	var payload string
+	err = tx.QueryRowContext(ctx, `SELECT status, attempts, payload FROM outbox_archive WHERE id = $1`, "archive-1").Scan(&status, &attempts, &payload)
+	require.NoError(t, err)
+	assert.Equal(t, core.MessageStatusSent, status)
+	assert.Equal(t, 1, attempts)
+	assert.Equal(t, "Payload", payload)
+
+	// Marking the message again is a no-op.
+	require.NoError(t, repo.MarkMessageAsSent(ctx, "archive-1", true))
+}
+
+func TestMarkMessageAsSent_ArchiveReusedID(t *testing.T) {
+	tx, ctx := setupTest(t)
+
+	repo, err := NewPostgresRepositoryWithConfigs(tx, PostgresConfigs{OnSent: SentMessagePolicyArchive})
+	require.NoError(t, err)
+
+	require.NoError(t, repo.SaveMessage(ctx, core.OutboxMessage{ID: "archive-1", Payload: "First", Status: core.MessageStatusPending}))
+	require.NoError(t, repo.MarkMessageAsSent(ctx, "archive-1", true))
+
+	// A new message reusing the ID must replace the archived one instead of being lost.
+	require.NoError(t, repo.SaveMessage(ctx, core.OutboxMessage{ID: "archive-1", Payload: "Second", Status: core.MessageStatusPending}))
+	require.NoError(t, repo.MarkMessageAsSent(ctx, "archive-1", false))
+
+	var count int
+	require.NoError(t, tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM outbox WHERE id = $1`, "archive-1").Scan(&count))
+	assert.Equal(t, 0, count, "Sent message was not moved")
+
+	var payload string
+	var attempts int
+	require.NoError(t, tx.QueryRowContext(ctx, `SELECT payload, attempts FROM outbox_archive WHERE id = $1`, "archive-1").Scan(&payload, &attempts))
+	assert.Equal(t, "Second", payload)
+	assert.Equal(t, 0, attempts)
+}
+
+func TestNewPostgresRepositoryWithConfigs_UnknownSentMessagePolicy(t *testing.T) {
+	_, err := NewPostgresRepositoryWithConfigs(nil, PostgresConfigs{OnSent: "archived"})
+
+	assert.EqualError(t, err, `unknown sent message policy "archived"`)
+}
+
+// insertExpiredMessages inserts messages created 10 days ago with the given status.
+func insertExpiredMessages(t *testing.T, ctx context.Context, tx *sql.Tx, status core.MessageStatus, ids ...string) {
+	for _, id := range ids {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO outbox (id, payload, status, created_at)
+			VALUES ($1, 'Payload', $2, NOW() - INTERVAL '10 days')`,
+			id, status,
+		)
+		require.NoError(t, err)
 	}
+}
+
+func TestDeleteMessages(t *testing.T) {
+	tx, ctx := setupTest(t)
+
+	repo := NewPostgresRepository(tx)
+
+	insertExpiredMessages(t, ctx, tx, core.MessageStatusSent, "expired-1", "expired-2", "expired-3")
+	insertExpiredMessages(t, ctx, tx, core.MessageStatusPending, "expired-4")
+	require.NoError(t, repo.SaveMessage(ctx, core.OutboxMessage{ID: "recent-1", Payload: "Payload", Status: core.MessageStatusSent}))
+	require.NoError(t, repo.MarkDestinationAsDelivered(ctx, "expired-1", "sqs"))
+
+	createdBefore := time.Now().Add(-7 * 24 * time.Hour)
+
+	deleted, err := repo.DeleteMessages(ctx, core.MessageStatusSent, createdBefore, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted)
+
+	deleted, err = repo.DeleteMessages(ctx, core.MessageStatusSent, createdBefore, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+
+	var remaining []string
+	rows, err := tx.QueryContext(ctx, `SELECT id FROM outbox ORDER BY id`)
+	require.NoError(t, err)
+	for rows.Next() {
+		var id string
+		require.NoError(t, rows.Scan(&id))
+		remaining = append(remaining, id)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{"expired-4", "recent-1"}, remaining)
+
+	destinations, err := repo.FetchDeliveredDestinations(ctx, "expired-1")
+	require.NoError(t, err)
+	assert.Empty(t, destinations)
+}
+
+func TestArchiveMessages(t *testing.T) {
+	tx, ctx := setupTest(t)
+
+	repo := NewPostgresRepository(tx)
+
+	insertExpiredMessages(t, ctx, tx, core.MessageStatusFailed, "expired-1", "expired-2")
+
+	archived, err := repo.ArchiveMessages(ctx, core.MessageStatusFailed, time.Now().Add(-7*24*time.Hour), 10)
+	require.NoError(t, err)
+	assert.Equal(t, 2, archived)
+
+	var count int
+	require.NoError(t, tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM outbox_archive WHERE status = $1`, core.MessageStatusFailed).Scan(&count))
+	assert.Equal(t, 2, count)
+
+	require.NoError(t, tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM outbox`).Scan(&count))
+	assert.Equal(t, 0, count)
+}
+
+func TestArchiveMessages_ReusedID(t *testing.T) {
+	tx, ctx := setupTest(t)
+
+	repo := NewPostgresRepository(tx)
+
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO outbox_archive (id, payload, status, available_at, created_at)
+		VALUES ('expired-1', 'Archived', $1, NOW() - INTERVAL '20 days', NOW() - INTERVAL '20 days')`,
+		core.MessageStatusFailed,
+	)
+	require.NoError(t, err)
+
+	insertExpiredMessages(t, ctx, tx, core.MessageStatusFailed, "expired-1")
+
+	archived, err := repo.ArchiveMessages(ctx, core.MessageStatusFailed, time.Now().Add(-7*24*time.Hour), 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, archived)
+
+	// The newer message must replace the archived one instead of being lost.
+	var payload string
+	require.NoError(t, tx.QueryRowContext(ctx, `SELECT payload FROM outbox_archive WHERE id = 'expired-1'`).Scan(&payload))
+	assert.Equal(t, "Payload", payload)
+
+	var count int
+	require.NoError(t, tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM outbox`).Scan(&count))
+	assert.Equal(t, 0, count)
+}
+
+func TestMarkMessageAsFailed(t *testing.T) {
+	tx, ctx := setupTest(t)
+
+	repo := NewPostgresRepository(tx)
 
 	// Insert a pending message
 	_, err := tx.ExecContext(ctx, `
@@ -166,12 +386,174 @@ func TestMarkMessageAsFailed(t *testing.T) {
 	require.NoError(t, err)
 
 	// Mark message as failed
-	err = repo.MarkMessageAsFailed(ctx, "5", "Error: Timeout")
+	err = repo.MarkMessageAsFailed(ctx, "5", false)
 	require.NoError(t, err)
 
 	// Verify in database
-	var status string
+	var status core.MessageStatus
 	err = tx.QueryRowContext(ctx, `SELECT status FROM outbox WHERE id = $1`, "5").Scan(&status)
 	require.NoError(t, err)
 	assert.Equal(t, core.MessageStatusFailed, status, "Message status was not updated to failed")
 }
+
+func TestMarkDestinationAsDelivered(t *testing.T) {
+	tx, ctx := setupTest(t)
+
+	repo := NewPostgresRepository(tx)
+
+	// Mark message as delivered to two destinations, one of them twice
+	require.NoError(t, repo.MarkDestinationAsDelivered(ctx, "6", "sqs"))
+	require.NoError(t, repo.MarkDestinationAsDelivered(ctx, "6", "kafka"))
+	require.NoError(t, repo.MarkDestinationAsDelivered(ctx, "6", "sqs"))
+
+	destinations, err := repo.FetchDeliveredDestinations(ctx, "6")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"sqs", "kafka"}, destinations)
+
+	destinations, err = repo.FetchDeliveredDestinations(ctx, "7")
+	require.NoError(t, err)
+	assert.Empty(t, destinations)
+}
+
+func TestClaimMessage(t *testing.T) {
+	tx, ctx := setupTest(t)
+
+	repo := NewPostgresRepository(tx)
+
+	require.NoError(t, repo.SaveMessage(ctx, core.OutboxMessage{ID: "claim-1", Payload: "Payload", Headers: map[string]string{"event-type": "order.created"}, Status: core.MessageStatusPending}))
+
+	message, ok, err := repo.ClaimMessage(ctx, "claim-1")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "Payload", message.Payload)
+	assert.Equal(t, map[string]string{"event-type": "order.created"}, message.Headers)
+	assert.Equal(t, core.MessageStatusProcessing, message.Status)
+
+	_, ok, err = repo.ClaimMessage(ctx, "claim-1")
+	require.NoError(t, err)
+	assert.False(t, ok, "Claimed messages must not be claimed again")
+
+	_, ok, err = repo.ClaimMessage(ctx, "unknown")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestSaveMessage_DebeziumColumns(t *testing.T) {
+	tx, ctx := setupTest(t)
+
+	repo, err := NewPostgresRepositoryWithConfigs(tx, PostgresConfigs{DebeziumColumns: true, NotifyChannel: "outbox_debezium_test"})
+	require.NoError(t, err)
+
+	err = repo.SaveMessage(ctx, core.OutboxMessage{
+		ID:          "debezium-1",
+		Payload:     `{"total":42}`,
+		OrderingKey: "order-7",
+		Headers:     map[string]string{core.HeaderAggregateType: "order", core.HeaderEventType: "OrderCreated"},
+		Status:      core.MessageStatusPending,
+	})
+	require.NoError(t, err)
+
+	var aggregateType, aggregateID, eventType string
+	err = tx.QueryRowContext(ctx, `SELECT aggregatetype, aggregateid, type FROM outbox WHERE id = $1`, "debezium-1").Scan(&aggregateType, &aggregateID, &eventType)
+	require.NoError(t, err)
+	assert.Equal(t, "order", aggregateType)
+	assert.Equal(t, "order-7", aggregateID)
+	assert.Equal(t, "OrderCreated", eventType)
+}
+
+func TestSaveMessage_DebeziumColumnsRequireAggregateType(t *testing.T) {
+	repo, err := NewPostgresRepositoryWithConfigs(nil, PostgresConfigs{DebeziumColumns: true})
+	require.NoError(t, err)
+
+	err = repo.SaveMessage(context.Background(), core.OutboxMessage{ID: "1", Payload: "Payload", Status: core.MessageStatusPending})
+
+	assert.ErrorContains(t, err, core.HeaderAggregateType)
+}
+
+func TestPartitionOf(t *testing.T) {
+	repo, err := NewPostgresRepositoryWithConfigs(nil, PostgresConfigs{PartitionCount: 16})
+	require.NoError(t, err)
+
+	first := repo.partitionOf(core.OutboxMessage{ID: "1", OrderingKey: "order-42"})
+	second := repo.partitionOf(core.OutboxMessage{ID: "2", OrderingKey: "order-42"})
+
+	assert.Equal(t, first, second, "Messages of the same key must share a partition")
+	assert.Less(t, first, uint32(16))
+	assert.Equal(t, uint32(0), NewPostgresRepository(nil).partitionOf(core.OutboxMessage{ID: "1", OrderingKey: "order-42"}))
+}
+
+// staticPartitions is a fixed partition assignment.
+type staticPartitions []uint32
+
+func (p staticPartitions) Partitions() []uint32 {
+	return p
+}
+
+func TestFetchPendingMessages_WithPartitions(t *testing.T) {
+	tx, ctx := setupTest(t)
+
+	repo, err := NewPostgresRepositoryWithConfigs(tx, PostgresConfigs{PartitionCount: 4})
+	require.NoError(t, err)
+
+	var expected []string
+	for i := 0; i < 8; i++ {
+		message := core.OutboxMessage{ID: fmt.Sprint(i), Payload: "Payload", OrderingKey: fmt.Sprintf("order-%d", i), Status: core.MessageStatusPending}
+		require.NoError(t, repo.SaveMessage(ctx, message))
+
+		if partition := repo.partitionOf(message); partition == 1 || partition == 3 {
+			expected = append(expected, message.ID)
+		}
+	}
+
+	messages, err := repo.WithPartitions(staticPartitions{1, 3}).FetchPendingMessages(ctx, 10, 30)
+	require.NoError(t, err)
+	assert.Len(t, messages, len(expected))
+
+	for _, message := range messages {
+		partition := repo.partitionOf(message)
+		assert.True(t, partition == 1 || partition == 3, "Message %s of partition %d was fetched", message.ID, partition)
+	}
+
+	messages, err = repo.WithPartitions(staticPartitions{}).FetchPendingMessages(ctx, 10, 30)
+	require.NoError(t, err)
+	assert.Empty(t, messages, "Instances without partitions must not fetch")
+
+	messages, err = repo.FetchPendingMessages(ctx, 10, 30)
+	require.NoError(t, err)
+	assert.NotEmpty(t, messages, "Unpartitioned fetches see every partition")
+}
+
+// TestConformance runs against committed rows, as NOW() does not advance within a transaction
+// and concurrent dispatchers only contend outside of a shared transaction.
+func TestConformance(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) core.OutboxMessageRepository {
+		if testDB == nil {
+			t.Skip("PostgreSQL test database is unavailable")
+		}
+
+		_, err := testDB.Exec(`TRUNCATE outbox, outbox_deliveries`)
+		require.NoError(t, err)
+
+		return NewPostgresRepository(testDB)
+	})
+}
+
+func TestConformance_SentMessagePolicies(t *testing.T) {
+	for _, policy := range []SentMessagePolicy{SentMessagePolicyDelete, SentMessagePolicyArchive} {
+		t.Run(string(policy), func(t *testing.T) {
+			repositorytest.Run(t, func(t *testing.T) core.OutboxMessageRepository {
+				if testDB == nil {
+					t.Skip("PostgreSQL test database is unavailable")
+				}
+
+				_, err := testDB.Exec(`TRUNCATE outbox, outbox_deliveries, outbox_archive`)
+				require.NoError(t, err)
+
+				repo, err := NewPostgresRepositoryWithConfigs(testDB, PostgresConfigs{OnSent: policy})
+				require.NoError(t, err)
+
+				return repo
+			})
+		})
+	}
+}
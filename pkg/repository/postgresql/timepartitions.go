@@ -0,0 +1,246 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TimePartitionInterval is the time range of each partition of a time-partitioned outbox.
+type TimePartitionInterval string
+
+const (
+	TimePartitionDaily  TimePartitionInterval = "daily"  // Partitions start at midnight UTC.
+	TimePartitionWeekly TimePartitionInterval = "weekly" // Partitions start on Monday at midnight UTC.
+)
+
+// timePartitionPrefix names partitions after the start of their range, as in outbox_p20250131.
+const timePartitionPrefix = "outbox_p"
+
+type TimePartitionConfigs struct {
+	Interval      TimePartitionInterval // Time range of each partition.
+	Premake       int                   // Number of future partitions kept ready, besides the current one.
+	CheckInterval time.Duration         // Delay between partition checks of the run loop. Zero uses the default.
+}
+
+func DefaultTimePartitionConfigs() TimePartitionConfigs {
+	return TimePartitionConfigs{
+		Interval:      TimePartitionDaily,
+		Premake:       7,
+		CheckInterval: 1 * time.Hour,
+	}
+}
+
+// TimePartitioner maintains an outbox partitioned by created_at (see MigratePartitioned). It
+// creates partitions ahead of time and drops old ones for a janitor, which removes a whole day or
+// week of messages at once instead of deleting them row by row.
+//
+// Messages are fetched through an index of unfinished messages, so the cost of fetching does not
+// grow with the number of partitions. Messages are updated by ID alone, which probes the primary
+// key of every partition, so the number of partitions should be bounded by a retention period.
+type TimePartitioner struct {
+	db      SQLExecutor
+	configs TimePartitionConfigs
+	now     func() time.Time
+}
+
+func NewTimePartitioner(db SQLExecutor, configs TimePartitionConfigs) *TimePartitioner {
+	return &TimePartitioner{
+		db:      db,
+		configs: configs,
+		now:     time.Now,
+	}
+}
+
+// Run creates partitions every CheckInterval until ctx is done, then returns the context's error.
+// Failed checks do not stop it; until partitions exist, messages are saved in the default partition.
+func (p *TimePartitioner) Run(ctx context.Context) error {
+	interval := p.configs.CheckInterval
+	if interval <= 0 {
+		interval = DefaultTimePartitionConfigs().CheckInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		_ = p.CreatePartitions(ctx)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// CreatePartitions creates the current partition and the next Premake ones, unless they exist.
+// Creating a partition fails when the default partition holds messages of its range, which
+// happens when partitions were not created in time; those messages must be moved out of it
+// first. The other partitions are still created, so later messages are not affected, and the
+// errors are returned together.
+func (p *TimePartitioner) CreatePartitions(ctx context.Context) error {
+	start := p.partitionStart(p.now())
+
+	var errs []error
+
+	for i := 0; i <= p.configs.Premake; i++ {
+		end := p.nextPartitionStart(start)
+
+		_, err := p.db.ExecContext(ctx, fmt.Sprintf(
+			"CREATE TABLE IF NOT EXISTS %s PARTITION OF outbox FOR VALUES FROM ('%s') TO ('%s')",
+			partitionName(start), start.Format(time.RFC3339), end.Format(time.RFC3339),
+		))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to create partition %s: %w", partitionName(start), err))
+		}
+
+		start = end
+	}
+
+	return errors.Join(errs...)
+}
+
+// DropPartitions drops the partitions whose range ended before createdBefore and which only hold
+// sent or failed messages. Partitions with unfinished messages are kept until they are finished.
+func (p *TimePartitioner) DropPartitions(ctx context.Context, createdBefore time.Time) (int, error) {
+	names, err := p.partitionNames(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	dropped := 0
+
+	for _, name := range names {
+		start, ok := parsePartitionName(name)
+		if !ok || p.nextPartitionStart(start).After(createdBefore) {
+			continue
+		}
+
+		var isDropped bool
+		err := withTx(ctx, p.db, func(db SQLExecutor) error {
+			var err error
+			isDropped, err = dropPartition(ctx, db, name)
+			return err
+		})
+		if err != nil {
+			return dropped, err
+		}
+
+		if isDropped {
+			dropped++
+		}
+	}
+
+	return dropped, nil
+}
+
+// dropPartition drops a partition and the delivery records of its messages, unless it holds
+// unfinished messages. The partition stays locked from the check to the drop, so no message can
+// be saved, claimed or retried in between. The outbox is locked first, as queries through it lock
+// it before its partitions, and dropping a partition needs that lock anyway.
+func dropPartition(ctx context.Context, db SQLExecutor, name string) (bool, error) {
+	if _, err := db.ExecContext(ctx, fmt.Sprintf("LOCK TABLE ONLY outbox, ONLY %s IN ACCESS EXCLUSIVE MODE", name)); err != nil {
+		return false, fmt.Errorf("failed to lock partition %s: %w", name, err)
+	}
+
+	var unfinished bool
+	rows, err := db.QueryContext(ctx, fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE status IN ('pending', 'processing'))", name))
+	if err != nil {
+		return false, fmt.Errorf("failed to check partition %s: %w", name, err)
+	}
+	if rows.Next() {
+		err = rows.Scan(&unfinished)
+	}
+	rows.Close()
+	if err != nil {
+		return false, fmt.Errorf("failed to check partition %s: %w", name, err)
+	}
+
+	if unfinished {
+		return false, nil
+	}
+
+	if _, err := db.ExecContext(ctx, fmt.Sprintf("DELETE FROM outbox_deliveries WHERE message_id IN (SELECT id FROM %s)", name)); err != nil {
+		return false, fmt.Errorf("failed to delete deliveries of partition %s: %w", name, err)
+	}
+
+	// Dropping the partition does not fire the trigger that releases the IDs of deleted messages.
+	if _, err := db.ExecContext(ctx, fmt.Sprintf("DELETE FROM outbox_message_ids WHERE id IN (SELECT id FROM %s)", name)); err != nil {
+		return false, fmt.Errorf("failed to release message IDs of partition %s: %w", name, err)
+	}
+
+	if _, err := db.ExecContext(ctx, "DROP TABLE "+name); err != nil {
+		return false, fmt.Errorf("failed to drop partition %s: %w", name, err)
+	}
+
+	return true, nil
+}
+
+func (p *TimePartitioner) partitionNames(ctx context.Context) ([]string, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT child.relname
+		FROM pg_inherits
+		JOIN pg_class child ON child.oid = pg_inherits.inhrelid
+		WHERE pg_inherits.inhparent = 'outbox'::regclass
+		ORDER BY child.relname`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list partitions: %w", err)
+	}
+
+	defer rows.Close()
+
+	var names []string
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to list partitions: %w", err)
+		}
+		names = append(names, name)
+	}
+
+	return names, rows.Err()
+}
+
+// partitionStart returns the start of the partition that holds messages created at t.
+func (p *TimePartitioner) partitionStart(t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+
+	if p.configs.Interval == TimePartitionWeekly {
+		daysSinceMonday := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -daysSinceMonday)
+	}
+
+	return day
+}
+
+func (p *TimePartitioner) nextPartitionStart(start time.Time) time.Time {
+	if p.configs.Interval == TimePartitionWeekly {
+		return start.AddDate(0, 0, 7)
+	}
+
+	return start.AddDate(0, 0, 1)
+}
+
+func partitionName(start time.Time) string {
+	return timePartitionPrefix + start.Format("20060102")
+}
+
+// parsePartitionName returns the start of a partition created by CreatePartitions. Other
+// partitions, such as the default one, are never dropped.
+func parsePartitionName(name string) (time.Time, bool) {
+	if !strings.HasPrefix(name, timePartitionPrefix) {
+		return time.Time{}, false
+	}
+
+	start, err := time.Parse("20060102", strings.TrimPrefix(name, timePartitionPrefix))
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return start, true
+}
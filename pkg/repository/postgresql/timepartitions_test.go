@@ -0,0 +1,224 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go-transactional-outbox/pkg/core"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimePartitioner_PartitionStart(t *testing.T) {
+	// A Friday, late in the evening east of UTC.
+	createdAt := time.Date(2025, 1, 31, 23, 30, 0, 0, time.FixedZone("UTC-2", -2*60*60))
+
+	daily := NewTimePartitioner(nil, TimePartitionConfigs{Interval: TimePartitionDaily})
+	assert.Equal(t, time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), daily.partitionStart(createdAt), "Partitions follow UTC days")
+	assert.Equal(t, time.Date(2025, 2, 2, 0, 0, 0, 0, time.UTC), daily.nextPartitionStart(daily.partitionStart(createdAt)))
+
+	weekly := NewTimePartitioner(nil, TimePartitionConfigs{Interval: TimePartitionWeekly})
+	assert.Equal(t, time.Date(2025, 1, 27, 0, 0, 0, 0, time.UTC), weekly.partitionStart(createdAt), "Weeks start on Monday")
+	assert.Equal(t, time.Date(2025, 1, 27, 0, 0, 0, 0, time.UTC), weekly.partitionStart(time.Date(2025, 1, 27, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, time.Date(2025, 2, 3, 0, 0, 0, 0, time.UTC), weekly.nextPartitionStart(weekly.partitionStart(createdAt)))
+}
+
+func TestPartitionName(t *testing.T) {
+	start := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, "outbox_p20250131", partitionName(start))
+
+	parsed, ok := parsePartitionName(partitionName(start))
+	assert.True(t, ok)
+	assert.Equal(t, start, parsed)
+
+	_, ok = parsePartitionName("outbox_default")
+	assert.False(t, ok)
+}
+
+// setupPartitionedOutbox migrates a partitioned outbox in its own schema, on a connection that
+// uses that schema, so it does not interfere with the unpartitioned test tables.
+func setupPartitionedOutbox(t *testing.T, configs TimePartitionConfigs) (*sql.Conn, context.Context) {
+	if testDB == nil {
+		t.Skip("PostgreSQL test database is unavailable")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+
+	conn, err := testDB.Conn(ctx)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_, _ = conn.ExecContext(context.Background(), `DROP SCHEMA IF EXISTS outbox_partitioned_test CASCADE; RESET search_path`)
+		conn.Close()
+	})
+
+	_, err = conn.ExecContext(ctx, `DROP SCHEMA IF EXISTS outbox_partitioned_test CASCADE; CREATE SCHEMA outbox_partitioned_test; SET search_path TO outbox_partitioned_test`)
+	require.NoError(t, err)
+
+	require.NoError(t, MigratePartitioned(ctx, conn, configs))
+
+	return conn, ctx
+}
+
+func TestTimePartitioner(t *testing.T) {
+	configs := TimePartitionConfigs{Interval: TimePartitionDaily, Premake: 2}
+	conn, ctx := setupPartitionedOutbox(t, configs)
+
+	now := time.Now()
+	partitioner := NewTimePartitioner(conn, configs)
+	partitioner.now = func() time.Time { return now }
+
+	names, err := partitioner.partitionNames(ctx)
+	require.NoError(t, err)
+	assert.Len(t, names, 4, "Migrating must create the default, current and two future partitions")
+
+	require.NoError(t, partitioner.CreatePartitions(ctx), "Creating partitions must be idempotent")
+
+	repo := NewPostgresRepository(conn)
+	require.NoError(t, repo.SaveMessage(ctx, core.OutboxMessage{ID: "1", Payload: "Payload", Status: core.MessageStatusPending}))
+
+	messages, err := repo.FetchPendingMessages(ctx, 10, 30)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+
+	// Partitions of the future are not dropped, and neither are partitions with unfinished messages.
+	dropped, err := partitioner.DropPartitions(ctx, now.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	assert.Equal(t, 0, dropped)
+
+	require.NoError(t, repo.MarkMessageAsSent(ctx, "1", true))
+
+	dropped, err = partitioner.DropPartitions(ctx, partitioner.nextPartitionStart(partitioner.partitionStart(now)))
+	require.NoError(t, err)
+	assert.Equal(t, 1, dropped)
+
+	var count int
+	require.NoError(t, conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM outbox`).Scan(&count))
+	assert.Equal(t, 0, count)
+}
+
+func TestMigratePartitioned_EnforcesUniqueIDs(t *testing.T) {
+	configs := TimePartitionConfigs{Interval: TimePartitionDaily, Premake: 1}
+	conn, ctx := setupPartitionedOutbox(t, configs)
+
+	now := time.Now()
+	partitioner := NewTimePartitioner(conn, configs)
+	partitioner.now = func() time.Time { return now }
+
+	repo := NewPostgresRepository(conn)
+	require.NoError(t, repo.SaveMessage(ctx, core.OutboxMessage{ID: "1", Payload: "Payload", Status: core.MessageStatusSent}))
+
+	// The same ID created at another time belongs to another partition, but is still rejected.
+	_, err := conn.ExecContext(ctx, `INSERT INTO outbox (id, payload, status, created_at) VALUES ('1', 'Payload', 'pending', $1)`, now.AddDate(0, 0, 1))
+	assert.ErrorContains(t, err, "outbox_message_ids_pkey")
+
+	dropped, err := partitioner.DropPartitions(ctx, partitioner.nextPartitionStart(partitioner.partitionStart(now)))
+	require.NoError(t, err)
+	assert.Equal(t, 1, dropped)
+
+	require.NoError(t, repo.SaveMessage(ctx, core.OutboxMessage{ID: "1", Payload: "Payload", Status: core.MessageStatusPending}), "Dropping a partition must release its IDs")
+
+	canceled, err := repo.CancelMessage(ctx, "1")
+	require.NoError(t, err)
+	require.True(t, canceled)
+	require.NoError(t, repo.SaveMessage(ctx, core.OutboxMessage{ID: "1", Payload: "Payload", Status: core.MessageStatusPending}), "Deleting a message must release its ID")
+}
+
+func TestTimePartitioner_DropPartitions_WaitsForPartitionLock(t *testing.T) {
+	configs := TimePartitionConfigs{Interval: TimePartitionDaily, Premake: 0}
+	conn, ctx := setupPartitionedOutbox(t, configs)
+
+	now := time.Now()
+	partitioner := NewTimePartitioner(conn, configs)
+	name := partitionName(partitioner.partitionStart(now))
+
+	// A transaction still reading the partition keeps it from being checked and dropped.
+	tx, err := testDB.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "LOCK TABLE outbox_partitioned_test."+name+" IN ACCESS SHARE MODE")
+	require.NoError(t, err)
+
+	_, err = conn.ExecContext(ctx, `SET lock_timeout = '100ms'`)
+	require.NoError(t, err)
+	t.Cleanup(func() { _, _ = conn.ExecContext(context.Background(), `RESET lock_timeout`) })
+
+	dropped, err := partitioner.DropPartitions(ctx, partitioner.nextPartitionStart(partitioner.partitionStart(now)))
+	assert.ErrorContains(t, err, "failed to lock partition "+name)
+	assert.Equal(t, 0, dropped)
+
+	require.NoError(t, tx.Rollback())
+
+	names, err := partitioner.partitionNames(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, names, name)
+}
+
+func TestTimePartitioner_CreatePartitions_ContinuesAfterFailure(t *testing.T) {
+	configs := TimePartitionConfigs{Interval: TimePartitionDaily, Premake: 0}
+	conn, ctx := setupPartitionedOutbox(t, configs)
+
+	partitioner := NewTimePartitioner(conn, TimePartitionConfigs{Interval: TimePartitionDaily, Premake: 2})
+
+	// A message of tomorrow lands in the default partition, so tomorrow's partition cannot be created.
+	tomorrow := partitioner.nextPartitionStart(partitioner.partitionStart(time.Now()))
+	_, err := conn.ExecContext(ctx, `INSERT INTO outbox (id, payload, status, created_at) VALUES ('1', 'Payload', 'sent', $1)`, tomorrow.Add(time.Hour))
+	require.NoError(t, err)
+
+	err = partitioner.CreatePartitions(ctx)
+	assert.ErrorContains(t, err, partitionName(tomorrow))
+
+	names, err := partitioner.partitionNames(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, names, partitionName(partitioner.nextPartitionStart(tomorrow)), "Later partitions must still be created")
+}
+
+// fakeExecutor fails statements that contain a given string, and records the others.
+type fakeExecutor struct {
+	SQLExecutor
+	failOn     string
+	statements []string
+}
+
+func (e *fakeExecutor) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if strings.Contains(query, e.failOn) {
+		return nil, errors.New("partition constraint would be violated")
+	}
+
+	e.statements = append(e.statements, query)
+
+	return nil, nil
+}
+
+func TestTimePartitioner_CreatePartitions_JoinsErrors(t *testing.T) {
+	now := time.Date(2025, 1, 31, 12, 0, 0, 0, time.UTC)
+	db := &fakeExecutor{failOn: "outbox_p20250131"}
+
+	partitioner := NewTimePartitioner(db, TimePartitionConfigs{Interval: TimePartitionDaily, Premake: 2})
+	partitioner.now = func() time.Time { return now }
+
+	err := partitioner.CreatePartitions(context.Background())
+
+	assert.ErrorContains(t, err, "outbox_p20250131")
+	require.Len(t, db.statements, 2, "Partitions after a failure must still be created")
+	assert.Contains(t, db.statements[0], "outbox_p20250201")
+	assert.Contains(t, db.statements[1], "outbox_p20250202")
+}
+
+func TestTimePartitioner_Run_DefaultsCheckInterval(t *testing.T) {
+	db := &fakeExecutor{failOn: "never"}
+	partitioner := NewTimePartitioner(db, TimePartitionConfigs{Interval: TimePartitionDaily})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.ErrorIs(t, partitioner.Run(ctx), context.Canceled)
+	assert.NotEmpty(t, db.statements, "Partitions must be created before the first check interval")
+}
@@ -0,0 +1,366 @@
+// Package repositorytest provides a conformance suite for core.OutboxMessageRepository
+// implementations, so every repository is held to the same semantics.
+package repositorytest
+
+import (
+	"context"
+	"fmt"
+	"go-transactional-outbox/pkg/core"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Factory returns a repository backed by an empty outbox. It is called once per test, and the
+// repository must be safe for concurrent use, as several fetchers share it.
+type Factory func(t *testing.T) core.OutboxMessageRepository
+
+// Run runs the conformance suite against the repositories created by factory. Repositories
+// implementing core.OutboxDeliveryRepository are also checked for delivery tracking, and those
+// implementing core.OutboxMessageScheduler for rescheduling and canceling.
+//
+// Repositories read the current time from their own clock (often the database's), so the
+// suite waits on the real clock; a full run takes a few seconds.
+func Run(t *testing.T, factory Factory) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, repo core.OutboxMessageRepository)
+	}{
+		{"FetchPendingMessages", testFetchPendingMessages},
+		{"FetchPendingMessages_RespectsLimit", testFetchPendingMessagesRespectsLimit},
+		{"FetchPendingMessages_SkipsLockedMessages", testFetchPendingMessagesSkipsLockedMessages},
+		{"FetchPendingMessages_ReclaimsExpiredLocks", testFetchPendingMessagesReclaimsExpiredLocks},
+		{"FetchPendingMessages_ConcurrentFetchers", testFetchPendingMessagesConcurrentFetchers},
+		{"MarkMessageForRetry_DelaysAvailability", testMarkMessageForRetryDelaysAvailability},
+		{"MarkMessageForRetry_IncrementsAttempts", testMarkMessageForRetryIncrementsAttempts},
+		{"MarkMessageAsSentAndFailed", testMarkMessageAsSentAndFailed},
+		{"MarkUnknownMessage", testMarkUnknownMessage},
+		{"MarkDestinationAsDelivered", testMarkDestinationAsDelivered},
+		{"SaveMessage_Scheduled", testSaveMessageScheduled},
+		{"RescheduleMessage", testRescheduleMessage},
+		{"CancelMessage", testCancelMessage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, factory(t))
+		})
+	}
+}
+
+func testFetchPendingMessages(t *testing.T, repo core.OutboxMessageRepository) {
+	ctx := context.Background()
+
+	saveMessage(t, repo, core.OutboxMessage{
+		ID:          "1",
+		Payload:     "Payload 1",
+		Headers:     map[string]string{"event-type": "order.created"},
+		OrderingKey: "order-42",
+		Status:      core.MessageStatusPending,
+	})
+	saveMessage(t, repo, core.OutboxMessage{ID: "2", Payload: "Payload 2", Status: core.MessageStatusPending})
+
+	messages, err := repo.FetchPendingMessages(ctx, 10, 30)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+
+	assert.Equal(t, "1", messages[0].ID, "Messages must be fetched oldest first")
+	assert.Equal(t, "Payload 1", messages[0].Payload)
+	assert.Equal(t, map[string]string{"event-type": "order.created"}, messages[0].Headers)
+	assert.Equal(t, "order-42", messages[0].OrderingKey)
+	assert.Equal(t, core.MessageStatusProcessing, messages[0].Status)
+	assert.Equal(t, uint8(0), messages[0].Attempts)
+
+	assert.Equal(t, "2", messages[1].ID)
+	assert.Empty(t, messages[1].Headers)
+	assert.Empty(t, messages[1].OrderingKey)
+}
+
+func testFetchPendingMessagesRespectsLimit(t *testing.T, repo core.OutboxMessageRepository) {
+	saveMessages(t, repo, "1", "2", "3")
+
+	messages, err := repo.FetchPendingMessages(context.Background(), 2, 30)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1", "2"}, ids(messages))
+}
+
+func testFetchPendingMessagesSkipsLockedMessages(t *testing.T, repo core.OutboxMessageRepository) {
+	ctx := context.Background()
+	saveMessages(t, repo, "1", "2")
+
+	messages, err := repo.FetchPendingMessages(ctx, 1, 30)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1"}, ids(messages))
+
+	messages, err = repo.FetchPendingMessages(ctx, 10, 30)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2"}, ids(messages), "Locked messages must not be fetched again")
+
+	messages, err = repo.FetchPendingMessages(ctx, 10, 30)
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+}
+
+func testFetchPendingMessagesReclaimsExpiredLocks(t *testing.T, repo core.OutboxMessageRepository) {
+	ctx := context.Background()
+	saveMessages(t, repo, "1")
+
+	messages, err := repo.FetchPendingMessages(ctx, 10, 1)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+
+	messages, err = repo.FetchPendingMessages(ctx, 10, 1)
+	require.NoError(t, err)
+	assert.Empty(t, messages, "Lock must hold until the timeout has elapsed")
+
+	time.Sleep(1100 * time.Millisecond)
+
+	messages, err = repo.FetchPendingMessages(ctx, 10, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1"}, ids(messages), "Messages with an expired lock must be fetched again")
+}
+
+func testFetchPendingMessagesConcurrentFetchers(t *testing.T, repo core.OutboxMessageRepository) {
+	ctx := context.Background()
+
+	for i := 0; i < 50; i++ {
+		require.NoError(t, repo.SaveMessage(ctx, core.OutboxMessage{ID: fmt.Sprint(i), Payload: "Payload", Status: core.MessageStatusPending}))
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	seen := make(map[string]int)
+
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				messages, err := repo.FetchPendingMessages(ctx, 4, 30)
+				if !assert.NoError(t, err) || len(messages) == 0 {
+					return
+				}
+				mu.Lock()
+				for _, message := range messages {
+					seen[message.ID]++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Len(t, seen, 50)
+	for id, count := range seen {
+		assert.Equal(t, 1, count, "Message %s was fetched more than once", id)
+	}
+}
+
+func testMarkMessageForRetryDelaysAvailability(t *testing.T, repo core.OutboxMessageRepository) {
+	ctx := context.Background()
+	saveMessages(t, repo, "1", "2")
+
+	_, err := repo.FetchPendingMessages(ctx, 10, 30)
+	require.NoError(t, err)
+
+	require.NoError(t, repo.MarkMessageForRetry(ctx, "1", time.Hour, false))
+	require.NoError(t, repo.MarkMessageForRetry(ctx, "2", 300*time.Millisecond, false))
+
+	messages, err := repo.FetchPendingMessages(ctx, 10, 30)
+	require.NoError(t, err)
+	assert.Empty(t, messages, "Messages must not be available before their retry delay")
+
+	time.Sleep(400 * time.Millisecond)
+
+	messages, err = repo.FetchPendingMessages(ctx, 10, 30)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2"}, ids(messages))
+	assert.Equal(t, uint8(0), messages[0].Attempts)
+}
+
+func testMarkMessageForRetryIncrementsAttempts(t *testing.T, repo core.OutboxMessageRepository) {
+	ctx := context.Background()
+	saveMessages(t, repo, "1")
+
+	for _, shouldIncrementAttempts := range []bool{true, false, true} {
+		_, err := repo.FetchPendingMessages(ctx, 10, 30)
+		require.NoError(t, err)
+		require.NoError(t, repo.MarkMessageForRetry(ctx, "1", 0, shouldIncrementAttempts))
+	}
+
+	messages, err := repo.FetchPendingMessages(ctx, 10, 30)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, uint8(2), messages[0].Attempts)
+	assert.Equal(t, core.MessageStatusProcessing, messages[0].Status)
+}
+
+func testMarkMessageAsSentAndFailed(t *testing.T, repo core.OutboxMessageRepository) {
+	ctx := context.Background()
+	saveMessages(t, repo, "1", "2", "3")
+
+	_, err := repo.FetchPendingMessages(ctx, 10, 30)
+	require.NoError(t, err)
+
+	require.NoError(t, repo.MarkMessageAsSent(ctx, "1", true))
+	require.NoError(t, repo.MarkMessageAsFailed(ctx, "2", false))
+
+	time.Sleep(10 * time.Millisecond)
+
+	// With no lock timeout, only the message that is still processing is fetched again;
+	// sent and failed messages are final.
+	messages, err := repo.FetchPendingMessages(ctx, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"3"}, ids(messages))
+}
+
+func testMarkUnknownMessage(t *testing.T, repo core.OutboxMessageRepository) {
+	ctx := context.Background()
+
+	assert.NoError(t, repo.MarkMessageAsSent(ctx, "unknown", true))
+	assert.NoError(t, repo.MarkMessageAsFailed(ctx, "unknown", true))
+	assert.NoError(t, repo.MarkMessageForRetry(ctx, "unknown", time.Second, true))
+
+	messages, err := repo.FetchPendingMessages(ctx, 10, 30)
+	require.NoError(t, err)
+	assert.Empty(t, messages, "Marking an unknown message must not create it")
+}
+
+func testMarkDestinationAsDelivered(t *testing.T, repo core.OutboxMessageRepository) {
+	deliveries, ok := repo.(core.OutboxDeliveryRepository)
+	if !ok {
+		t.Skip("Repository does not implement core.OutboxDeliveryRepository")
+	}
+
+	ctx := context.Background()
+
+	require.NoError(t, deliveries.MarkDestinationAsDelivered(ctx, "1", "sqs"))
+	require.NoError(t, deliveries.MarkDestinationAsDelivered(ctx, "1", "kafka"))
+	require.NoError(t, deliveries.MarkDestinationAsDelivered(ctx, "1", "sqs"))
+
+	destinations, err := deliveries.FetchDeliveredDestinations(ctx, "1")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"sqs", "kafka"}, destinations)
+
+	destinations, err = deliveries.FetchDeliveredDestinations(ctx, "2")
+	require.NoError(t, err)
+	assert.Empty(t, destinations)
+}
+
+func testSaveMessageScheduled(t *testing.T, repo core.OutboxMessageRepository) {
+	ctx := context.Background()
+
+	later := core.OutboxMessage{ID: "1", Payload: "Payload 1", Status: core.MessageStatusPending}
+	later.ScheduleIn(time.Hour)
+	saveMessage(t, repo, later)
+
+	soon := core.OutboxMessage{ID: "2", Payload: "Payload 2", Status: core.MessageStatusPending}
+	soon.ScheduleIn(300 * time.Millisecond)
+	saveMessage(t, repo, soon)
+
+	saveMessages(t, repo, "3")
+
+	messages, err := repo.FetchPendingMessages(ctx, 10, 30)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"3"}, ids(messages), "Scheduled messages must not be available before their time")
+
+	time.Sleep(400 * time.Millisecond)
+
+	messages, err = repo.FetchPendingMessages(ctx, 10, 30)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2"}, ids(messages))
+}
+
+func testRescheduleMessage(t *testing.T, repo core.OutboxMessageRepository) {
+	scheduler, ok := repo.(core.OutboxMessageScheduler)
+	if !ok {
+		t.Skip("Repository does not implement core.OutboxMessageScheduler")
+	}
+
+	ctx := context.Background()
+
+	message := core.OutboxMessage{ID: "1", Payload: "Payload 1", Status: core.MessageStatusPending}
+	message.ScheduleIn(time.Hour)
+	saveMessage(t, repo, message)
+	saveMessages(t, repo, "2")
+
+	rescheduled, err := scheduler.RescheduleMessage(ctx, "2", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.True(t, rescheduled)
+
+	rescheduled, err = scheduler.RescheduleMessage(ctx, "1", time.Now())
+	require.NoError(t, err)
+	assert.True(t, rescheduled)
+
+	time.Sleep(10 * time.Millisecond)
+
+	messages, err := repo.FetchPendingMessages(ctx, 10, 30)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1"}, ids(messages))
+
+	rescheduled, err = scheduler.RescheduleMessage(ctx, "1", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.False(t, rescheduled, "Messages being dispatched must not be rescheduled")
+
+	rescheduled, err = scheduler.RescheduleMessage(ctx, "unknown", time.Now())
+	require.NoError(t, err)
+	assert.False(t, rescheduled)
+}
+
+func testCancelMessage(t *testing.T, repo core.OutboxMessageRepository) {
+	scheduler, ok := repo.(core.OutboxMessageScheduler)
+	if !ok {
+		t.Skip("Repository does not implement core.OutboxMessageScheduler")
+	}
+
+	ctx := context.Background()
+
+	message := core.OutboxMessage{ID: "1", Payload: "Payload 1", Status: core.MessageStatusPending}
+	message.ScheduleIn(300 * time.Millisecond)
+	saveMessage(t, repo, message)
+	saveMessages(t, repo, "2")
+
+	canceled, err := scheduler.CancelMessage(ctx, "1")
+	require.NoError(t, err)
+	assert.True(t, canceled)
+
+	canceled, err = scheduler.CancelMessage(ctx, "1")
+	require.NoError(t, err)
+	assert.False(t, canceled, "Canceled messages must not be canceled again")
+
+	time.Sleep(400 * time.Millisecond)
+
+	messages, err := repo.FetchPendingMessages(ctx, 10, 30)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2"}, ids(messages), "Canceled messages must never be dispatched")
+
+	canceled, err = scheduler.CancelMessage(ctx, "2")
+	require.NoError(t, err)
+	assert.False(t, canceled, "Messages being dispatched must not be canceled")
+}
+
+func saveMessage(t *testing.T, repo core.OutboxMessageRepository, message core.OutboxMessage) {
+	require.NoError(t, repo.SaveMessage(context.Background(), message))
+
+	// Keeps creation times distinct on clocks with a coarse resolution, so fetch order is deterministic.
+	time.Sleep(time.Millisecond)
+}
+
+func saveMessages(t *testing.T, repo core.OutboxMessageRepository, ids ...string) {
+	for _, id := range ids {
+		saveMessage(t, repo, core.OutboxMessage{ID: id, Payload: "Payload " + id, Status: core.MessageStatusPending})
+	}
+}
+
+func ids(messages []core.OutboxMessage) []string {
+	ids := make([]string, 0, len(messages))
+	for _, message := range messages {
+		ids = append(ids, message.ID)
+	}
+
+	return ids
+}
@@ -0,0 +1,12 @@
+//go:build sqlite_cgo
+
+package sqlite
+
+import (
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const (
+	testDriverName = "sqlite3"
+	testDSNOptions = "?_busy_timeout=5000"
+)
@@ -0,0 +1,13 @@
+//go:build !sqlite_cgo
+
+package sqlite
+
+import (
+	_ "modernc.org/sqlite"
+)
+
+// The pure-Go driver is used by default; run the tests with -tags sqlite_cgo to use the cgo driver instead.
+const (
+	testDriverName = "sqlite"
+	testDSNOptions = "?_pragma=busy_timeout(5000)"
+)
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS outbox (
+	id TEXT NOT NULL PRIMARY KEY,
+	payload TEXT NOT NULL,
+	headers TEXT NULL,
+	ordering_key TEXT NOT NULL DEFAULT '',
+	status TEXT NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	available_at INTEGER NOT NULL, -- Unix time in microseconds
+	created_at INTEGER NOT NULL,   -- Unix time in microseconds
+	picked_at INTEGER NULL         -- Unix time in microseconds
+);
+
+CREATE INDEX IF NOT EXISTS outbox_status_available_at_idx ON outbox (status, available_at);
+
+CREATE TABLE IF NOT EXISTS outbox_deliveries (
+	message_id TEXT NOT NULL,
+	destination TEXT NOT NULL,
+	delivered_at INTEGER NOT NULL,
+	PRIMARY KEY (message_id, destination)
+);
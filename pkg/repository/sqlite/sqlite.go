@@ -0,0 +1,323 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"go-transactional-outbox/pkg/core"
+	"strings"
+	"time"
+)
+
+// SQLExecutor represents shared methods between *sql.DB and *sql.Tx
+type SQLExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// conner is implemented by *sql.DB. Claiming messages needs a dedicated connection to run
+// BEGIN IMMEDIATE, which database/sql cannot express in a driver-independent way.
+type conner interface {
+	Conn(ctx context.Context) (*sql.Conn, error)
+}
+
+// SQLiteRepository stores the outbox in SQLite. It only uses plain SQL and parameters, so it
+// works with both cgo (github.com/mattn/go-sqlite3) and pure-Go (modernc.org/sqlite) drivers.
+//
+// SQLite has no SKIP LOCKED; messages are claimed in an immediate transaction instead, which
+// takes the database write lock up front so concurrent dispatchers are serialized.
+type SQLiteRepository struct {
+	db  SQLExecutor
+	now func() time.Time
+}
+
+func NewSQLiteRepository(db SQLExecutor) *SQLiteRepository {
+	return &SQLiteRepository{
+		db:  db,
+		now: time.Now,
+	}
+}
+
+func (r *SQLiteRepository) SaveMessage(ctx context.Context, message core.OutboxMessage) error {
+	headers, err := encodeHeaders(message.Headers)
+	if err != nil {
+		return err
+	}
+
+	now := r.now()
+
+	availableAt := message.AvailableAt
+	if availableAt.IsZero() {
+		availableAt = now
+	}
+
+	_, err = r.db.ExecContext(ctx,
+		"INSERT INTO outbox (id, payload, headers, ordering_key, status, attempts, available_at, created_at) VALUES (?, ?, ?, ?, ?, 0, ?, ?)",
+		message.ID, message.Payload, headers, message.OrderingKey, message.Status, availableAt.UnixMicro(), now.UnixMicro())
+	return err
+}
+
+func (r *SQLiteRepository) FetchPendingMessages(ctx context.Context, limit uint32, processingLockTimeout uint32) ([]core.OutboxMessage, error) {
+	var messages []core.OutboxMessage
+
+	err := r.withImmediateTx(ctx, func(db SQLExecutor) error {
+		now := r.now()
+
+		rows, err := db.QueryContext(ctx, `
+			SELECT id
+			FROM outbox
+			WHERE available_at <= ?
+				AND (status = ? OR (status = ? AND picked_at < ?))
+			ORDER BY available_at ASC, created_at ASC
+			LIMIT ?`,
+			now.UnixMicro(),
+			core.MessageStatusPending,
+			core.MessageStatusProcessing,
+			now.Add(-time.Duration(processingLockTimeout)*time.Second).UnixMicro(),
+			limit,
+		)
+		if err != nil {
+			return err
+		}
+
+		var ids []interface{}
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return err
+			}
+			ids = append(ids, id)
+		}
+		rows.Close()
+
+		if err := rows.Err(); err != nil || len(ids) == 0 {
+			return err
+		}
+
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+
+		_, err = db.ExecContext(ctx,
+			"UPDATE outbox SET status = ?, picked_at = ? WHERE id IN ("+placeholders+")",
+			append([]interface{}{core.MessageStatusProcessing, now.UnixMicro()}, ids...)...,
+		)
+		if err != nil {
+			return err
+		}
+
+		messages, err = queryMessages(ctx, db, "id IN ("+placeholders+")", ids...)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+func (r *SQLiteRepository) MarkMessageAsSent(ctx context.Context, id string, shouldIncrementAttempts bool) error {
+	return r.updateMessageStatus(ctx, id, core.MessageStatusSent, shouldIncrementAttempts)
+}
+
+func (r *SQLiteRepository) MarkMessageAsFailed(ctx context.Context, id string, shouldIncrementAttempts bool) error {
+	return r.updateMessageStatus(ctx, id, core.MessageStatusFailed, shouldIncrementAttempts)
+}
+
+func (r *SQLiteRepository) MarkMessageForRetry(ctx context.Context, id string, delay time.Duration, shouldIncrementAttempts bool) error {
+	query := "UPDATE outbox SET status = ?"
+
+	if shouldIncrementAttempts {
+		query += ", attempts = attempts + 1"
+	}
+
+	query += ", available_at = ?"
+
+	query += " WHERE id = ?"
+
+	_, err := r.db.ExecContext(ctx, query, core.MessageStatusPending, r.now().Add(delay).UnixMicro(), id)
+
+	return err
+}
+
+func (r *SQLiteRepository) RescheduleMessage(ctx context.Context, id string, availableAt time.Time) (bool, error) {
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE outbox SET available_at = ? WHERE id = ? AND status = ?",
+		availableAt.UnixMicro(), id, core.MessageStatusPending)
+	if err != nil {
+		return false, err
+	}
+
+	return isAffected(result)
+}
+
+// CancelMessage deletes a pending message with its delivery records.
+func (r *SQLiteRepository) CancelMessage(ctx context.Context, id string) (bool, error) {
+	canceled := false
+
+	err := r.withImmediateTx(ctx, func(db SQLExecutor) error {
+		result, err := db.ExecContext(ctx, "DELETE FROM outbox WHERE id = ? AND status = ?", id, core.MessageStatusPending)
+		if err != nil {
+			return err
+		}
+
+		if canceled, err = isAffected(result); err != nil || !canceled {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, "DELETE FROM outbox_deliveries WHERE message_id = ?", id)
+		return err
+	})
+
+	return canceled, err
+}
+
+func (r *SQLiteRepository) FetchDeliveredDestinations(ctx context.Context, messageID string) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT destination FROM outbox_deliveries WHERE message_id = ?", messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var destinations []string
+
+	for rows.Next() {
+		var destination string
+		if err := rows.Scan(&destination); err != nil {
+			return nil, err
+		}
+		destinations = append(destinations, destination)
+	}
+
+	return destinations, rows.Err()
+}
+
+func (r *SQLiteRepository) MarkDestinationAsDelivered(ctx context.Context, messageID string, destination string) error {
+	_, err := r.db.ExecContext(ctx,
+		"INSERT OR IGNORE INTO outbox_deliveries (message_id, destination, delivered_at) VALUES (?, ?, ?)",
+		messageID, destination, r.now().UnixMicro())
+	return err
+}
+
+func (r *SQLiteRepository) updateMessageStatus(ctx context.Context, id string, status core.MessageStatus, shouldIncrementAttempts bool) error {
+	query := "UPDATE outbox SET status = ?"
+
+	if shouldIncrementAttempts {
+		query += ", attempts = attempts + 1"
+	}
+
+	query += " WHERE id = ?"
+
+	_, err := r.db.ExecContext(ctx, query, status, id)
+
+	return err
+}
+
+// withImmediateTx runs fn in a BEGIN IMMEDIATE transaction on a dedicated connection, or
+// directly when the executor is already a transaction.
+func (r *SQLiteRepository) withImmediateTx(ctx context.Context, fn func(db SQLExecutor) error) error {
+	pool, ok := r.db.(conner)
+	if !ok {
+		return fn(r.db)
+	}
+
+	conn, err := pool.Conn(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+		return err
+	}
+
+	// COMMIT and ROLLBACK must run even when ctx is done, or the connection returns to the pool
+	// mid-transaction, holding the write lock.
+	if err := fn(conn); err != nil {
+		rollback(ctx, conn)
+		return err
+	}
+
+	if _, err := conn.ExecContext(context.WithoutCancel(ctx), "COMMIT"); err != nil {
+		rollback(ctx, conn)
+		return err
+	}
+
+	return nil
+}
+
+// rollback ends the transaction on conn, or discards the connection when that fails, so it is
+// never reused mid-transaction.
+func rollback(ctx context.Context, conn *sql.Conn) {
+	if _, err := conn.ExecContext(context.WithoutCancel(ctx), "ROLLBACK"); err != nil {
+		_ = conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+	}
+}
+
+func queryMessages(ctx context.Context, db SQLExecutor, condition string, args ...interface{}) ([]core.OutboxMessage, error) {
+	rows, err := db.QueryContext(ctx,
+		"SELECT id, payload, headers, ordering_key, status, attempts FROM outbox WHERE "+condition+" ORDER BY available_at ASC, created_at ASC",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var messages []core.OutboxMessage
+
+	for rows.Next() {
+		var message core.OutboxMessage
+		var headers sql.NullString
+		if err := rows.Scan(&message.ID, &message.Payload, &headers, &message.OrderingKey, &message.Status, &message.Attempts); err != nil {
+			return nil, err
+		}
+		if message.Headers, err = decodeHeaders(headers.String); err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+
+	return messages, rows.Err()
+}
+
+func isAffected(result sql.Result) (bool, error) {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+// encodeHeaders converts message headers to the JSON stored in the headers column.
+func encodeHeaders(headers map[string]string) (interface{}, error) {
+	if len(headers) == 0 {
+		return nil, nil
+	}
+
+	encoded, err := json.Marshal(headers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode message headers: %w", err)
+	}
+
+	return string(encoded), nil
+}
+
+func decodeHeaders(encoded string) (map[string]string, error) {
+	if encoded == "" {
+		return nil, nil
+	}
+
+	var headers map[string]string
+	if err := json.Unmarshal([]byte(encoded), &headers); err != nil {
+		return nil, fmt.Errorf("failed to decode message headers: %w", err)
+	}
+
+	return headers, nil
+}
@@ -0,0 +1,274 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go-transactional-outbox/pkg/core"
+	"go-transactional-outbox/pkg/repository/repositorytest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupDatabase opens a migrated database in a temporary file. A file is used instead of
+// :memory: because every pooled connection would otherwise get its own empty database.
+func setupDatabase(t *testing.T) (*sql.DB, context.Context) {
+	db, err := sql.Open(testDriverName, filepath.Join(t.TempDir(), "outbox.db")+testDSNOptions)
+	require.NoError(t, err, "Failed to open test database")
+	t.Cleanup(func() { db.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+
+	require.NoError(t, Migrate(ctx, db), "Failed to migrate test database")
+
+	return db, ctx
+}
+
+func TestMigrate_IsIdempotent(t *testing.T) {
+	db, ctx := setupDatabase(t)
+
+	assert.NoError(t, Migrate(ctx, db))
+}
+
+func TestSaveMessage(t *testing.T) {
+	db, ctx := setupDatabase(t)
+
+	repo := NewSQLiteRepository(db)
+
+	message := core.OutboxMessage{
+		ID:      "1",
+		Payload: "Test Payload",
+		Headers: map[string]string{"event-type": "order.created"},
+		Status:  core.MessageStatusPending,
+	}
+
+	err := repo.SaveMessage(ctx, message)
+	require.NoError(t, err, "Failed to save message")
+
+	var count int
+	err = db.QueryRowContext(ctx, `SELECT COUNT(*) FROM outbox WHERE id = ?`, message.ID).Scan(&count)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count, "Message was not saved correctly")
+}
+
+func TestSaveMessage_InTransaction(t *testing.T) {
+	db, ctx := setupDatabase(t)
+
+	tx, err := db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, NewSQLiteRepository(tx).SaveMessage(ctx, core.OutboxMessage{ID: "1", Payload: "Payload", Status: core.MessageStatusPending}))
+	require.NoError(t, tx.Rollback())
+
+	messages, err := NewSQLiteRepository(db).FetchPendingMessages(ctx, 10, 30)
+	require.NoError(t, err)
+	assert.Empty(t, messages, "Rolled back messages must not be fetched")
+}
+
+func TestFetchPendingMessages(t *testing.T) {
+	db, ctx := setupDatabase(t)
+
+	repo := NewSQLiteRepository(db)
+
+	for i, payload := range []string{"Payload 1", "Payload 2"} {
+		err := repo.SaveMessage(ctx, core.OutboxMessage{
+			ID:          fmt.Sprint(i + 2),
+			Payload:     payload,
+			Headers:     map[string]string{"index": fmt.Sprint(i)},
+			OrderingKey: "order-42",
+			Status:      core.MessageStatusPending,
+		})
+		require.NoError(t, err)
+	}
+
+	messages, err := repo.FetchPendingMessages(ctx, 10, 30)
+	require.NoError(t, err)
+	require.Len(t, messages, 2, "Expected 2 pending messages")
+
+	assert.Equal(t, "Payload 1", messages[0].Payload)
+	assert.Equal(t, "Payload 2", messages[1].Payload)
+	assert.Equal(t, map[string]string{"index": "0"}, messages[0].Headers)
+	assert.Equal(t, "order-42", messages[0].OrderingKey)
+	assert.Equal(t, core.MessageStatusProcessing, messages[0].Status)
+
+	messages, err = repo.FetchPendingMessages(ctx, 10, 30)
+	require.NoError(t, err)
+	assert.Empty(t, messages, "Locked messages must not be fetched again")
+}
+
+func TestFetchPendingMessages_ReclaimsExpiredLocks(t *testing.T) {
+	db, ctx := setupDatabase(t)
+
+	now := time.Now()
+	repo := NewSQLiteRepository(db)
+	repo.now = func() time.Time { return now }
+
+	require.NoError(t, repo.SaveMessage(ctx, core.OutboxMessage{ID: "1", Payload: "Payload", Status: core.MessageStatusPending}))
+
+	messages, err := repo.FetchPendingMessages(ctx, 10, 30)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+
+	now = now.Add(31 * time.Second)
+
+	messages, err = repo.FetchPendingMessages(ctx, 10, 30)
+	require.NoError(t, err)
+	require.Len(t, messages, 1, "Messages with an expired lock must be fetched again")
+}
+
+func TestMarkMessageAsSent(t *testing.T) {
+	db, ctx := setupDatabase(t)
+
+	repo := NewSQLiteRepository(db)
+
+	require.NoError(t, repo.SaveMessage(ctx, core.OutboxMessage{ID: "4", Payload: "Payload Sent", Status: core.MessageStatusPending}))
+
+	// Mark message as sent
+	err := repo.MarkMessageAsSent(ctx, "4", true)
+	require.NoError(t, err)
+
+	// Verify in database
+	var status core.MessageStatus
+	var attempts uint8
+	err = db.QueryRowContext(ctx, `SELECT status, attempts FROM outbox WHERE id = ?`, "4").Scan(&status, &attempts)
+	require.NoError(t, err)
+	assert.Equal(t, core.MessageStatusSent, status, "Message status was not updated to sent")
+	assert.Equal(t, uint8(1), attempts)
+}
+
+func TestMarkMessageAsFailed(t *testing.T) {
+	db, ctx := setupDatabase(t)
+
+	repo := NewSQLiteRepository(db)
+
+	require.NoError(t, repo.SaveMessage(ctx, core.OutboxMessage{ID: "5", Payload: "Payload Failed", Status: core.MessageStatusPending}))
+
+	// Mark message as failed
+	err := repo.MarkMessageAsFailed(ctx, "5", false)
+	require.NoError(t, err)
+
+	// Verify in database
+	var status core.MessageStatus
+	var attempts uint8
+	err = db.QueryRowContext(ctx, `SELECT status, attempts FROM outbox WHERE id = ?`, "5").Scan(&status, &attempts)
+	require.NoError(t, err)
+	assert.Equal(t, core.MessageStatusFailed, status, "Message status was not updated to failed")
+	assert.Equal(t, uint8(0), attempts)
+}
+
+func TestMarkMessageForRetry(t *testing.T) {
+	db, ctx := setupDatabase(t)
+
+	repo := NewSQLiteRepository(db)
+
+	require.NoError(t, repo.SaveMessage(ctx, core.OutboxMessage{ID: "6", Payload: "Payload Retry", Status: core.MessageStatusPending}))
+
+	_, err := repo.FetchPendingMessages(ctx, 10, 30)
+	require.NoError(t, err)
+
+	err = repo.MarkMessageForRetry(ctx, "6", time.Hour, true)
+	require.NoError(t, err)
+
+	messages, err := repo.FetchPendingMessages(ctx, 10, 30)
+	require.NoError(t, err)
+	assert.Empty(t, messages, "Message must not be available before its retry delay")
+
+	err = repo.MarkMessageForRetry(ctx, "6", 0, true)
+	require.NoError(t, err)
+
+	messages, err = repo.FetchPendingMessages(ctx, 10, 30)
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, uint8(2), messages[0].Attempts)
+}
+
+func TestMarkDestinationAsDelivered(t *testing.T) {
+	db, ctx := setupDatabase(t)
+
+	repo := NewSQLiteRepository(db)
+
+	require.NoError(t, repo.MarkDestinationAsDelivered(ctx, "7", "sqs"))
+	require.NoError(t, repo.MarkDestinationAsDelivered(ctx, "7", "kafka"))
+	require.NoError(t, repo.MarkDestinationAsDelivered(ctx, "7", "sqs"))
+
+	destinations, err := repo.FetchDeliveredDestinations(ctx, "7")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"sqs", "kafka"}, destinations)
+}
+
+func TestFetchPendingMessages_ConcurrentFetchers(t *testing.T) {
+	db, ctx := setupDatabase(t)
+
+	repo := NewSQLiteRepository(db)
+
+	for i := 0; i < 50; i++ {
+		require.NoError(t, repo.SaveMessage(ctx, core.OutboxMessage{ID: fmt.Sprint(i), Payload: "Payload", Status: core.MessageStatusPending}))
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	seen := make(map[string]int)
+
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				messages, err := repo.FetchPendingMessages(ctx, 4, 30)
+				if !assert.NoError(t, err) || len(messages) == 0 {
+					return
+				}
+				mu.Lock()
+				for _, message := range messages {
+					seen[message.ID]++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Len(t, seen, 50)
+	for id, count := range seen {
+		assert.Equal(t, 1, count, "Message %s was fetched more than once", id)
+	}
+}
+
+func TestConformance(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) core.OutboxMessageRepository {
+		db, _ := setupDatabase(t)
+		return NewSQLiteRepository(db)
+	})
+}
+
+func TestWithImmediateTx_CommitsWhenContextIsCanceled(t *testing.T) {
+	db, ctx := setupDatabase(t)
+
+	repo := NewSQLiteRepository(db)
+
+	txCtx, cancel := context.WithCancel(ctx)
+
+	err := repo.withImmediateTx(txCtx, func(tx SQLExecutor) error {
+		_, err := tx.ExecContext(txCtx, "INSERT INTO outbox (id, payload, status, attempts, available_at, created_at) VALUES ('1', 'Payload', 'pending', 0, 0, 0)")
+		cancel()
+		return err
+	})
+	require.NoError(t, err)
+
+	// The write lock must be released, or this write waits for the busy timeout and fails.
+	writeCtx, writeCancel := context.WithTimeout(ctx, time.Second)
+	defer writeCancel()
+
+	require.NoError(t, repo.SaveMessage(writeCtx, core.OutboxMessage{ID: "2", Payload: "Payload", Status: core.MessageStatusPending}))
+
+	var count int
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT COUNT(*) FROM outbox").Scan(&count))
+	assert.Equal(t, 2, count)
+}